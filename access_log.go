@@ -0,0 +1,48 @@
+package logger
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// AccessLogFields is the fixed schema of an AccessLog entry.
+type AccessLogFields struct {
+	Method       string
+	Path         string
+	Status       int
+	BytesWritten int64
+	Duration     time.Duration
+	UserIP       string
+	RequestID    string
+	Referer      string
+	UserAgent    string
+}
+
+// AccessLog writes one "access" entry at Info level with every field of
+// fields, empty or not, so access logs share the same keys. The request ID
+// and user IP replace any stored in ctx rather than being logged twice.
+func (l *Logger) AccessLog(ctx context.Context, fields AccessLogFields) {
+	ctx, keysAndValues := l.accessLogEntry(ctx, fields)
+	l.log(ctx, zapcore.InfoLevel, "access", keysAndValues)
+}
+
+func (l *Logger) accessLogEntry(ctx context.Context, fields AccessLogFields) (context.Context, []any) {
+	if fields.RequestID != "" {
+		ctx = l.SetRequestID(ctx, fields.RequestID)
+	}
+	if fields.UserIP != "" {
+		ctx = l.SetUserIP(ctx, fields.UserIP)
+	}
+
+	return ctx, []any{
+		"method", fields.Method,
+		"path", fields.Path,
+		"status", fields.Status,
+		"bytes_written", fields.BytesWritten,
+		"duration_ms", float64(fields.Duration) / float64(time.Millisecond),
+		"referer", fields.Referer,
+		"user_agent", fields.UserAgent,
+	}
+}
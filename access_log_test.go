@@ -0,0 +1,74 @@
+package logger_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cyrus-wg/go-logger"
+)
+
+var accessLogKeys = []string{"method", "path", "status", "bytes_written", "duration_ms", "user_ip", "request_id", "referer", "user_agent"}
+
+func TestAccessLog(t *testing.T) {
+	tests := []struct {
+		name   string
+		log    func(l *logger.Logger)
+		values map[string]any
+	}{
+		{
+			name: "AccessLog",
+			log: func(l *logger.Logger) {
+				l.AccessLog(context.Background(), logger.AccessLogFields{
+					Method:       "POST",
+					Path:         "/orders",
+					Status:       201,
+					BytesWritten: 18,
+					Duration:     1500 * time.Microsecond,
+					UserIP:       "203.0.113.7",
+					RequestID:    "req-1",
+				})
+			},
+			values: map[string]any{"method": "POST", "status": float64(201), "bytes_written": float64(18), "duration_ms": 1.5, "request_id": "req-1", "referer": "", "user_agent": ""},
+		},
+		{
+			name: "middleware",
+			log: func(l *logger.Logger) {
+				handler := l.LoggerMiddlewareWithConfig(logger.MiddlewareConfig{AccessLog: true})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusCreated)
+					io.WriteString(w, `{"id":"order-123"}`)
+				}))
+				req := httptest.NewRequest(http.MethodPost, "/orders", nil)
+				req.Header.Set("X-Request-ID", "req-1")
+				req.Header.Set("User-Agent", "curl/8.5")
+				req.Header.Set("X-Forwarded-For", "203.0.113.7")
+				handler.ServeHTTP(httptest.NewRecorder(), req)
+			},
+			values: map[string]any{"method": "POST", "status": float64(201), "bytes_written": float64(18), "request_id": "req-1", "user_ip": "203.0.113.7", "user_agent": "curl/8.5", "referer": ""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, buf := newLogger(t, logger.LoggerConfig{})
+			tt.log(l)
+
+			line := decodeLine(t, buf)
+			if line["message"] != "access" || line["level"] != "INFO" {
+				t.Errorf("logged %v %v, want an Info access entry", line["level"], line["message"])
+			}
+			for _, k := range accessLogKeys {
+				if _, ok := line[k]; !ok {
+					t.Errorf("missing schema key %s", k)
+				}
+			}
+			for k, v := range tt.values {
+				if line[k] != v {
+					t.Errorf("%s = %v, want %v", k, line[k], v)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,222 @@
+package logger_test
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/cyrus-wg/go-logger"
+	"go.uber.org/zap"
+)
+
+// nextLine returns the line after the one it is called on, where the logging
+// call under test is.
+func nextLine() int {
+	_, _, line, _ := runtime.Caller(1)
+	return line + 1
+}
+
+// TestCallerAttribution checks that every API reports the caller's file and
+// line, which relies on each path having the call depth that the base
+// logger's caller skip expects.
+func TestCallerAttribution(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		log  func(l *logger.Logger) int
+	}{
+		{"Info", func(l *logger.Logger) int {
+			line := nextLine()
+			l.Info(ctx, "msg")
+			return line
+		}},
+		{"Infof", func(l *logger.Logger) int {
+			line := nextLine()
+			l.Infof(ctx, "%s", "msg")
+			return line
+		}},
+		{"Infow", func(l *logger.Logger) int {
+			line := nextLine()
+			l.Infow(ctx, "msg", "k", "v")
+			return line
+		}},
+		{"Infoln", func(l *logger.Logger) int {
+			line := nextLine()
+			l.Infoln(ctx, "msg")
+			return line
+		}},
+		{"Logw", func(l *logger.Logger) int {
+			line := nextLine()
+			l.Logw(ctx, logger.InfoLevel, "msg")
+			return line
+		}},
+		{"LogMap", func(l *logger.Logger) int {
+			line := nextLine()
+			l.LogMap(ctx, logger.InfoLevel, "msg", map[string]any{"k": "v"})
+			return line
+		}},
+		{"InfowIf", func(l *logger.Logger) int {
+			line := nextLine()
+			l.InfowIf(ctx, true, "msg")
+			return line
+		}},
+		{"WithFields", func(l *logger.Logger) int {
+			line := nextLine()
+			l.WithFields("k", "v").Info(ctx, "msg")
+			return line
+		}},
+		{"ContextLogger", func(l *logger.Logger) int {
+			cl := l.WithContext(ctx)
+			line := nextLine()
+			cl.Info("msg")
+			return line
+		}},
+		{"Check", func(l *logger.Logger) int {
+			line := nextLine()
+			entry := l.Check(ctx, logger.InfoLevel, "msg")
+			entry.Write(zap.String("k", "v"))
+			return line
+		}},
+		{"LogFields", func(l *logger.Logger) int {
+			line := nextLine()
+			l.LogFields(ctx, logger.InfoLevel, "msg", zap.String("k", "v"))
+			return line
+		}},
+		{"AccessLog", func(l *logger.Logger) int {
+			line := nextLine()
+			l.AccessLog(ctx, logger.AccessLogFields{Method: "GET"})
+			return line
+		}},
+		{"RequestScope", func(l *logger.Logger) int {
+			scope := l.NewRequestScope(ctx)
+			line := nextLine()
+			scope.Finish("msg")
+			return line
+		}},
+		{"Writer", func(l *logger.Logger) int {
+			w := l.Writer(logger.InfoLevel)
+			line := nextLine()
+			w.Write([]byte("msg\n"))
+			return line
+		}},
+		{"StdLogAt", func(l *logger.Logger) int {
+			std := l.StdLogAt(logger.InfoLevel)
+			line := nextLine()
+			std.Print("msg")
+			return line
+		}},
+		{"SlogHandler", func(l *logger.Logger) int {
+			sl := slog.New(l.SlogHandler())
+			line := nextLine()
+			sl.InfoContext(ctx, "msg")
+			return line
+		}},
+		{"global Info", func(l *logger.Logger) int {
+			line := nextLine()
+			logger.Info(ctx, "msg")
+			return line
+		}},
+		{"global Infow", func(l *logger.Logger) int {
+			line := nextLine()
+			logger.Infow(ctx, "msg", "k", "v")
+			return line
+		}},
+		{"global Logw", func(l *logger.Logger) int {
+			line := nextLine()
+			logger.Logw(ctx, logger.InfoLevel, "msg")
+			return line
+		}},
+		{"global Check", func(l *logger.Logger) int {
+			line := nextLine()
+			logger.Check(ctx, logger.InfoLevel, "msg").Write()
+			return line
+		}},
+		{"global LogFields", func(l *logger.Logger) int {
+			line := nextLine()
+			logger.LogFields(ctx, logger.InfoLevel, "msg")
+			return line
+		}},
+		{"global AccessLog", func(l *logger.Logger) int {
+			line := nextLine()
+			logger.AccessLog(ctx, logger.AccessLogFields{Method: "GET"})
+			return line
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, buf := newLogger(t, logger.LoggerConfig{})
+			if err := logger.InitGlobalLogger(logger.LoggerConfig{Output: buf, Encoding: "json"}); err != nil {
+				t.Fatal(err)
+			}
+			defer logger.DestroyGlobalLogger()
+
+			line := tt.log(l)
+
+			want := fmt.Sprintf("/caller_test.go:%d", line)
+			got, _ := decodeLine(t, buf)["caller"].(string)
+			if !strings.HasSuffix(got, want) {
+				t.Errorf("caller = %q, want suffix %q", got, want)
+			}
+		})
+	}
+}
+
+// callerFunction returns the name of the function it is called from.
+func callerFunction() string {
+	pc, _, _, _ := runtime.Caller(1)
+	return runtime.FuncForPC(pc).Name()
+}
+
+func TestFunctionName(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		log  func(l *logger.Logger) string
+	}{
+		{"Info", func(l *logger.Logger) string {
+			l.Info(ctx, "msg")
+			return callerFunction()
+		}},
+		{"Errorw", func(l *logger.Logger) string {
+			l.Errorw(ctx, "msg", "k", "v")
+			return callerFunction()
+		}},
+		{"WithFields", func(l *logger.Logger) string {
+			l.WithFields("k", "v").Warn(ctx, "msg")
+			return callerFunction()
+		}},
+		{"ContextLogger", func(l *logger.Logger) string {
+			l.WithContext(ctx).Infof("%s", "msg")
+			return callerFunction()
+		}},
+		{"global Info", func(l *logger.Logger) string {
+			logger.Info(ctx, "msg")
+			return callerFunction()
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, buf := newLogger(t, logger.LoggerConfig{IncludeFunctionName: true, DisableStacktrace: true})
+			if err := logger.InitGlobalLogger(logger.LoggerConfig{Output: buf, Encoding: "json", IncludeFunctionName: true}); err != nil {
+				t.Fatal(err)
+			}
+			defer logger.DestroyGlobalLogger()
+
+			want := tt.log(l)
+			if got := decodeLine(t, buf)["function"]; got != want {
+				t.Errorf("function = %v, want %s", got, want)
+			}
+		})
+	}
+
+	l, buf := newLogger(t, logger.LoggerConfig{})
+	l.Info(ctx, "msg")
+	if got, ok := decodeLine(t, buf)["function"]; ok {
+		t.Errorf("function = %v without IncludeFunctionName", got)
+	}
+}
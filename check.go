@@ -0,0 +1,134 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// LogEntry is an entry that passed the level and sampling checks and is
+// waiting for its fields, returned by Check.
+type LogEntry struct {
+	logger *Logger
+	ctx    context.Context
+	entry  *zapcore.CheckedEntry
+}
+
+// Check returns an entry to write if a message at level would be logged, and
+// nil otherwise, so building expensive fields can be skipped:
+//
+//	if entry := l.Check(ctx, logger.DebugLevel, "cache state"); entry != nil {
+//		entry.Write(zap.Any("entries", cache.Snapshot()))
+//	}
+func (l *Logger) Check(ctx context.Context, level Level, msg string) *LogEntry {
+	return l.check(ctx, level, msg)
+}
+
+// check has the same call depth as Logger.log so the caller skip of the base
+// logger holds for Check and its global counterpart.
+func (l *Logger) check(ctx context.Context, level Level, msg string) *LogEntry {
+	zl, err := level.zapLevel()
+	if err != nil {
+		zl = zapcore.InfoLevel
+	}
+
+	if l.sanitizeMessages {
+		msg = sanitizeMessage(msg)
+	}
+
+	base := l.base
+	if IsTraced(ctx) {
+		base = l.traceBase
+	}
+
+	entry := base.Check(zl, msg)
+	if entry == nil {
+		return nil
+	}
+	return &LogEntry{logger: l, ctx: ctx, entry: entry}
+}
+
+// LogFields logs at level with pre-built zap fields, skipping the
+// conversion of sugared key-value pairs. The context fields are added as
+// with the other methods.
+func (l *Logger) LogFields(ctx context.Context, level Level, msg string, fields ...zap.Field) {
+	if entry := l.check(ctx, level, msg); entry != nil {
+		entry.Write(fields...)
+	}
+}
+
+// ContextFields returns the fields every entry logged with ctx starts with,
+// such as the request ID, user and fixed fields, as zap fields.
+func (l *Logger) ContextFields(ctx context.Context) []zap.Field {
+	return attributesToFields(l.combineAttributes(ctx))
+}
+
+// Write logs the entry with the context attributes and fields. It must be
+// called at most once.
+func (e *LogEntry) Write(fields ...zap.Field) {
+	if e == nil {
+		return
+	}
+
+	// The fields are appended after the context attributes are converted
+	// rather than passed to combineAttributes, which would box each one
+	attributes := e.logger.combineAttributes(e.ctx)
+	if len(fields) > 0 {
+		attributes = e.logger.openGroups(attributes)
+	}
+	combined := appendAttributeFields(make([]zap.Field, 0, len(attributes)/2+len(fields)), attributes)
+	for _, field := range fields {
+		combined = append(combined, e.logger.truncateField(e.logger.redactField(field)))
+	}
+
+	if e.logger.onError != nil && e.entry.Level >= zapcore.ErrorLevel {
+		notify := func() { e.logger.notifyError(e.ctx, e.entry.Message, fieldsToAttributes(combined)) }
+		if e.entry.Level >= zapcore.FatalLevel {
+			e.entry = e.entry.After(e.entry.Entry, exitAfter(notify))
+		} else {
+			defer notify()
+		}
+	}
+	e.entry.Write(combined...)
+}
+
+// fieldsToAttributes converts zap fields into a sugared key-value slice of
+// zap.Field entries.
+func fieldsToAttributes(fields []zap.Field) []any {
+	keysAndValues := make([]any, len(fields))
+	for i, field := range fields {
+		keysAndValues[i] = field
+	}
+	return keysAndValues
+}
+
+// attributesToFields converts a sugared key-value slice into zap fields the
+// way the sugared logger does. Non-string keys are formatted with fmt and a
+// trailing key without a value is dropped.
+func attributesToFields(keysAndValues []any) []zap.Field {
+	return appendAttributeFields(make([]zap.Field, 0, len(keysAndValues)/2), keysAndValues)
+}
+
+// appendAttributeFields is attributesToFields appending to fields.
+func appendAttributeFields(fields []zap.Field, keysAndValues []any) []zap.Field {
+	for i := 0; i < len(keysAndValues); {
+		if field, ok := keysAndValues[i].(zapcore.Field); ok {
+			fields = append(fields, field)
+			i++
+			continue
+		}
+
+		if i+1 >= len(keysAndValues) {
+			break
+		}
+		key, ok := keysAndValues[i].(string)
+		if !ok {
+			key = fmt.Sprint(keysAndValues[i])
+		}
+		fields = append(fields, zap.Any(key, keysAndValues[i+1]))
+		i += 2
+	}
+	return fields
+}
@@ -0,0 +1,130 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// ContextLogger is a Logger bound to a context so calls don't need a ctx
+// argument. The request ID, user and extra fields are read from the context
+// when each entry is logged, not when it is bound.
+type ContextLogger struct {
+	logger *Logger
+	ctx    context.Context
+}
+
+// WithContext binds ctx to l.
+func (l *Logger) WithContext(ctx context.Context) *ContextLogger {
+	return &ContextLogger{logger: l, ctx: ctx}
+}
+
+// Context returns the bound context.
+func (c *ContextLogger) Context() context.Context {
+	return c.ctx
+}
+
+func (c *ContextLogger) Debug(args ...any) {
+	c.logger.log(c.ctx, zapcore.DebugLevel, sprint(args...), nil)
+}
+
+func (c *ContextLogger) Info(args ...any) {
+	c.logger.log(c.ctx, zapcore.InfoLevel, sprint(args...), nil)
+}
+
+func (c *ContextLogger) Warn(args ...any) {
+	c.logger.log(c.ctx, zapcore.WarnLevel, sprint(args...), nil)
+}
+
+func (c *ContextLogger) Error(args ...any) {
+	c.logger.log(c.ctx, zapcore.ErrorLevel, sprint(args...), nil)
+}
+
+func (c *ContextLogger) Panic(args ...any) {
+	c.logger.log(c.ctx, zapcore.PanicLevel, sprint(args...), nil)
+}
+
+func (c *ContextLogger) Fatal(args ...any) {
+	c.logger.log(c.ctx, zapcore.FatalLevel, sprint(args...), nil)
+}
+
+func (c *ContextLogger) Debugf(template string, args ...any) {
+	c.logger.log(c.ctx, zapcore.DebugLevel, fmt.Sprintf(template, args...), nil)
+}
+
+func (c *ContextLogger) Infof(template string, args ...any) {
+	c.logger.log(c.ctx, zapcore.InfoLevel, fmt.Sprintf(template, args...), nil)
+}
+
+func (c *ContextLogger) Warnf(template string, args ...any) {
+	c.logger.log(c.ctx, zapcore.WarnLevel, fmt.Sprintf(template, args...), nil)
+}
+
+func (c *ContextLogger) Errorf(template string, args ...any) {
+	c.logger.log(c.ctx, zapcore.ErrorLevel, fmt.Sprintf(template, args...), nil)
+}
+
+func (c *ContextLogger) Panicf(template string, args ...any) {
+	c.logger.log(c.ctx, zapcore.PanicLevel, fmt.Sprintf(template, args...), nil)
+}
+
+func (c *ContextLogger) Fatalf(template string, args ...any) {
+	c.logger.log(c.ctx, zapcore.FatalLevel, fmt.Sprintf(template, args...), nil)
+}
+
+func (c *ContextLogger) Debugln(args ...any) {
+	c.logger.log(c.ctx, zapcore.DebugLevel, sprintln(args...), nil)
+}
+
+func (c *ContextLogger) Infoln(args ...any) {
+	c.logger.log(c.ctx, zapcore.InfoLevel, sprintln(args...), nil)
+}
+
+func (c *ContextLogger) Warnln(args ...any) {
+	c.logger.log(c.ctx, zapcore.WarnLevel, sprintln(args...), nil)
+}
+
+func (c *ContextLogger) Errorln(args ...any) {
+	c.logger.log(c.ctx, zapcore.ErrorLevel, sprintln(args...), nil)
+}
+
+func (c *ContextLogger) Panicln(args ...any) {
+	c.logger.log(c.ctx, zapcore.PanicLevel, sprintln(args...), nil)
+}
+
+func (c *ContextLogger) Fatalln(args ...any) {
+	c.logger.log(c.ctx, zapcore.FatalLevel, sprintln(args...), nil)
+}
+
+func (c *ContextLogger) Debugw(msg string, keysAndValues ...any) {
+	c.logger.log(c.ctx, zapcore.DebugLevel, msg, keysAndValues)
+}
+
+func (c *ContextLogger) Infow(msg string, keysAndValues ...any) {
+	c.logger.log(c.ctx, zapcore.InfoLevel, msg, keysAndValues)
+}
+
+func (c *ContextLogger) Warnw(msg string, keysAndValues ...any) {
+	c.logger.log(c.ctx, zapcore.WarnLevel, msg, keysAndValues)
+}
+
+func (c *ContextLogger) Errorw(msg string, keysAndValues ...any) {
+	c.logger.log(c.ctx, zapcore.ErrorLevel, msg, keysAndValues)
+}
+
+func (c *ContextLogger) Panicw(msg string, keysAndValues ...any) {
+	c.logger.log(c.ctx, zapcore.PanicLevel, msg, keysAndValues)
+}
+
+func (c *ContextLogger) Fatalw(msg string, keysAndValues ...any) {
+	c.logger.log(c.ctx, zapcore.FatalLevel, msg, keysAndValues)
+}
+
+func (c *ContextLogger) Logw(level Level, msg string, keysAndValues ...any) {
+	zl, err := level.zapLevel()
+	if err != nil {
+		zl = zapcore.InfoLevel
+	}
+	c.logger.log(c.ctx, zl, msg, keysAndValues)
+}
@@ -0,0 +1,68 @@
+package logger_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cyrus-wg/go-logger"
+)
+
+type attemptKey struct{}
+
+func TestContextLogger(t *testing.T) {
+	tests := []struct {
+		name      string
+		log       func(c *logger.ContextLogger)
+		wantMsg   string
+		wantLevel string
+		wantK     any
+	}{
+		{"Info", func(c *logger.ContextLogger) { c.Info("charge", 42) }, "charge42", "INFO", nil},
+		{"Infof", func(c *logger.ContextLogger) { c.Infof("charge %d", 42) }, "charge 42", "INFO", nil},
+		{"Infoln", func(c *logger.ContextLogger) { c.Infoln("charge", 42) }, "charge 42", "INFO", nil},
+		{"Warnw", func(c *logger.ContextLogger) { c.Warnw("charge", "k", "v") }, "charge", "WARN", "v"},
+		{"Logw", func(c *logger.ContextLogger) { c.Logw(logger.ErrorLevel, "charge", "k", "v") }, "charge", "ERROR", "v"},
+		{"Debug", func(c *logger.ContextLogger) { c.Debug("charge") }, "charge", "DEBUG", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempt := new(int)
+			l, buf := newLogger(t, logger.LoggerConfig{
+				DisableStacktrace: true,
+				FieldExtractors: []logger.FieldExtractor{func(ctx context.Context) (string, any, bool) {
+					n, ok := ctx.Value(attemptKey{}).(*int)
+					if !ok {
+						return "", nil, false
+					}
+					return "attempt", *n, true
+				}},
+			})
+			ctx := l.SetRequestID(context.Background(), "req-1")
+			ctx = context.WithValue(ctx, attemptKey{}, attempt)
+			c := l.WithContext(ctx)
+			if c.Context() != ctx {
+				t.Error("Context() does not return the bound context")
+			}
+
+			// Values changed after binding are read when logging
+			*attempt = 2
+			l.SetFixedKeyValue("deployment_color", "green")
+			tt.log(c)
+
+			line := decodeLine(t, buf)
+			want := map[string]any{
+				"message":          tt.wantMsg,
+				"level":            tt.wantLevel,
+				"request_id":       "req-1",
+				"attempt":          float64(2),
+				"deployment_color": "green",
+				"k":                tt.wantK,
+			}
+			for k, v := range want {
+				if line[k] != v {
+					t.Errorf("%s = %v, want %v", k, line[k], v)
+				}
+			}
+		})
+	}
+}
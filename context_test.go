@@ -0,0 +1,461 @@
+package logger_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cyrus-wg/go-logger"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestUserIP(t *testing.T) {
+	l, buf := newLogger(t, logger.LoggerConfig{})
+	ctx := l.SetUserIP(context.Background(), "203.0.113.7")
+
+	if ip, ok := l.GetUserIP(ctx); !ok || ip != "203.0.113.7" {
+		t.Errorf("GetUserIP = %q, %t, want 203.0.113.7, true", ip, ok)
+	}
+	if _, ok := l.GetUserIP(context.Background()); ok {
+		t.Error("GetUserIP found an IP in an empty context")
+	}
+
+	l.Info(ctx, "direct")
+	l.Info(l.DetachContext(ctx), "detached")
+	for _, line := range decodeLines(t, buf) {
+		if line["user_ip"] != "203.0.113.7" {
+			t.Errorf("%v: user_ip = %v, want 203.0.113.7", line["message"], line["user_ip"])
+		}
+	}
+}
+
+func TestTraceContext(t *testing.T) {
+	spanContext := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+		SpanID:     trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+		TraceFlags: trace.FlagsSampled,
+	})
+	tests := []struct {
+		name    string
+		enabled bool
+		ctx     context.Context
+		want    bool
+	}{
+		{"enabled", true, trace.ContextWithSpanContext(context.Background(), spanContext), true},
+		{"disabled", false, trace.ContextWithSpanContext(context.Background(), spanContext), false},
+		{"no span", true, context.Background(), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, buf := newLogger(t, logger.LoggerConfig{EnableTraceContext: tt.enabled})
+			l.Info(tt.ctx, "msg")
+
+			line := decodeLine(t, buf)
+			if !tt.want {
+				if _, ok := line["trace_id"]; ok {
+					t.Errorf("unexpected trace_id = %v", line["trace_id"])
+				}
+				return
+			}
+			if line["trace_id"] != "4bf92f3577b34da6a3ce929d0e0e4736" {
+				t.Errorf("trace_id = %v, want 4bf92f3577b34da6a3ce929d0e0e4736", line["trace_id"])
+			}
+			if line["span_id"] != "00f067aa0ba902b7" {
+				t.Errorf("span_id = %v, want 00f067aa0ba902b7", line["span_id"])
+			}
+		})
+	}
+}
+
+type localeKey struct{}
+
+func TestDetachContextWith(t *testing.T) {
+	l, _ := newLogger(t, logger.LoggerConfig{})
+	ctx, cancel := context.WithCancel(context.Background())
+	ctx = l.SetRequestID(ctx, "req-1")
+	ctx = context.WithValue(ctx, localeKey{}, "de-CH")
+	ctx = context.WithValue(ctx, "unlisted", true)
+	cancel()
+
+	tests := []struct {
+		name       string
+		detached   context.Context
+		wantLocale any
+	}{
+		{"DetachContextWith", l.DetachContextWith(ctx, localeKey{}), "de-CH"},
+		{"DetachContext", l.DetachContext(ctx), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.detached.Value(localeKey{}); got != tt.wantLocale {
+				t.Errorf("locale = %v, want %v", got, tt.wantLocale)
+			}
+			if got := tt.detached.Value("unlisted"); got != nil {
+				t.Errorf("unlisted key copied: %v", got)
+			}
+			if id, _ := l.GetRequestID(tt.detached); id != "req-1" {
+				t.Errorf("request ID = %q, want req-1", id)
+			}
+			if err := tt.detached.Err(); err != nil {
+				t.Errorf("detached context is done: %v", err)
+			}
+		})
+	}
+}
+
+type account struct {
+	ID           string
+	Role         string
+	PasswordHash string
+}
+
+func TestUserFormatter(t *testing.T) {
+	user := account{ID: "u-42", Role: "admin", PasswordHash: "secret"}
+	tests := []struct {
+		name      string
+		formatter func(user any) any
+		want      any
+	}{
+		{"no formatter", nil, map[string]any{"ID": "u-42", "Role": "admin", "PasswordHash": "secret"}},
+		{"ID only", func(user any) any { return user.(account).ID }, "u-42"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, buf := newLogger(t, logger.LoggerConfig{UserFormatter: tt.formatter})
+			ctx := l.SetUser(context.Background(), user)
+			l.Info(ctx, "msg")
+
+			if got := decodeLine(t, buf)["user"]; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("user = %v, want %v", got, tt.want)
+			}
+			if stored, _ := l.GetUser(ctx); stored != user {
+				t.Errorf("GetUser = %v, want the unformatted user", stored)
+			}
+		})
+	}
+}
+
+func TestContextAccessors(t *testing.T) {
+	l, _ := newLogger(t, logger.LoggerConfig{})
+	var fromMiddleware string
+	handler := l.LoggerMiddleware(false, false)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fromMiddleware, _ = logger.RequestIDFromContext(r.Context())
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Request-ID", "req-mw")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if fromMiddleware != "req-mw" {
+		t.Errorf("RequestIDFromContext in handler = %q, want req-mw", fromMiddleware)
+	}
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		wantID string
+		wantOK bool
+		user   any
+	}{
+		{"set by logger", l.SetUser(l.SetRequestID(context.Background(), "req-1"), "alice"), "req-1", true, "alice"},
+		{"empty", context.Background(), "", false, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if id, ok := logger.RequestIDFromContext(tt.ctx); id != tt.wantID || ok != tt.wantOK {
+				t.Errorf("RequestIDFromContext = %q, %t, want %q, %t", id, ok, tt.wantID, tt.wantOK)
+			}
+			if user, ok := logger.UserFromContext(tt.ctx); user != tt.user || ok != tt.wantOK {
+				t.Errorf("UserFromContext = %v, %t, want %v, %t", user, ok, tt.user, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestWithWorkerID(t *testing.T) {
+	l, buf := newLogger(t, logger.LoggerConfig{})
+	ctx := l.SetRequestID(context.Background(), "req-1")
+	detached := l.DetachContext(ctx)
+
+	var wg sync.WaitGroup
+	for _, id := range []string{"worker-a", "worker-b"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			workerCtx := l.WithWorkerID(detached, id)
+			for i := range 3 {
+				l.Infow(l.DetachContext(workerCtx), "processed", "worker", id, "item", i)
+			}
+		}()
+	}
+	wg.Wait()
+
+	lines := decodeLines(t, buf)
+	if len(lines) != 6 {
+		t.Fatalf("got %d lines, want 6", len(lines))
+	}
+	for _, line := range lines {
+		if line["worker_id"] != line["worker"] {
+			t.Errorf("line from %v has worker_id = %v", line["worker"], line["worker_id"])
+		}
+		if line["request_id"] != "req-1" {
+			t.Errorf("request_id = %v, want req-1", line["request_id"])
+		}
+	}
+}
+
+func TestIncludeDeadline(t *testing.T) {
+	tests := []struct {
+		name     string
+		include  bool
+		timeout  time.Duration
+		min, max float64
+		absent   bool
+	}{
+		{"100ms left", true, 100 * time.Millisecond, 50, 100, false},
+		{"passed", true, -time.Second, -1100, -1000, false},
+		{"no deadline", true, 0, 0, 0, true},
+		{"disabled", false, 100 * time.Millisecond, 0, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, buf := newLogger(t, logger.LoggerConfig{IncludeDeadline: tt.include})
+			ctx := context.Background()
+			if tt.timeout != 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+			l.Info(ctx, "msg")
+
+			remaining, ok := decodeLine(t, buf)["deadline_remaining_ms"].(float64)
+			if ok == tt.absent {
+				t.Fatalf("has deadline_remaining_ms = %t, want %t", ok, !tt.absent)
+			}
+			if !tt.absent && (remaining < tt.min || remaining > tt.max) {
+				t.Errorf("deadline_remaining_ms = %v, want between %v and %v", remaining, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestGo(t *testing.T) {
+	tests := []struct {
+		name      string
+		fn        func(l *logger.Logger, done chan<- struct{}) func(ctx context.Context)
+		wantMsg   string
+		wantLevel string
+	}{
+		{
+			name: "panic",
+			fn: func(l *logger.Logger, done chan<- struct{}) func(ctx context.Context) {
+				return func(ctx context.Context) { panic("nil map write") }
+			},
+			wantMsg:   "Recovered from panic in goroutine",
+			wantLevel: "ERROR",
+		},
+		{
+			name: "outlives parent",
+			fn: func(l *logger.Logger, done chan<- struct{}) func(ctx context.Context) {
+				return func(ctx context.Context) {
+					time.Sleep(10 * time.Millisecond)
+					l.Infow(ctx, "background work", "canceled", ctx.Err() != nil)
+					done <- struct{}{}
+				}
+			},
+			wantMsg:   "background work",
+			wantLevel: "INFO",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			done := make(chan struct{}, 1)
+			l, buf := newLogger(t, logger.LoggerConfig{
+				DisableStacktrace: true,
+				OnError: func(context.Context, string, map[string]any) {
+					done <- struct{}{}
+				},
+			})
+			ctx, cancel := context.WithCancel(l.SetRequestID(context.Background(), "req-1"))
+			l.Go(ctx, tt.fn(l, done))
+			cancel()
+
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				t.Fatal("goroutine did not finish")
+			}
+			line := decodeLine(t, buf)
+			if line["message"] != tt.wantMsg || line["level"] != tt.wantLevel {
+				t.Errorf("logged %v %v, want %s %s", line["level"], line["message"], tt.wantLevel, tt.wantMsg)
+			}
+			if line["request_id"] != "req-1" {
+				t.Errorf("request_id = %v, want req-1", line["request_id"])
+			}
+			if tt.wantLevel == "ERROR" {
+				if line["panic"] != "nil map write" {
+					t.Errorf("panic = %v, want nil map write", line["panic"])
+				}
+				if stack, _ := line["stack"].(string); !strings.Contains(stack, "TestGo") {
+					t.Errorf("stack does not show the panicking function:\n%s", stack)
+				}
+			} else if line["canceled"] != false {
+				t.Errorf("goroutine context canceled = %v with its parent", line["canceled"])
+			}
+		})
+	}
+}
+
+func TestAppendField(t *testing.T) {
+	l, buf := newLogger(t, logger.LoggerConfig{})
+	parent := l.SetRequestID(context.Background(), "req-1")
+	ctx := l.AppendField(parent, "order_id", 7)
+	ctx = l.AppendField(ctx, "payment", "card")
+	sibling := l.AppendField(parent, "refund", true)
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		want   map[string]any
+		absent []string
+	}{
+		{"appended", ctx, map[string]any{"order_id": float64(7), "payment": "card", "request_id": "req-1"}, []string{"refund"}},
+		{"detached", l.DetachContext(ctx), map[string]any{"order_id": float64(7), "payment": "card", "request_id": "req-1"}, nil},
+		{"parent", parent, nil, []string{"order_id", "payment", "refund"}},
+		{"sibling", sibling, map[string]any{"refund": true}, []string{"order_id", "payment"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf.Reset()
+			l.Info(tt.ctx, "later")
+
+			line := decodeLine(t, buf)
+			for k, v := range tt.want {
+				if line[k] != v {
+					t.Errorf("%s = %v, want %v", k, line[k], v)
+				}
+			}
+			for _, k := range tt.absent {
+				if _, ok := line[k]; ok {
+					t.Errorf("unexpected %s = %v", k, line[k])
+				}
+			}
+		})
+	}
+}
+
+func TestDetachGroupContext(t *testing.T) {
+	l, buf := newLogger(t, logger.LoggerConfig{})
+	parent, cancelParent := context.WithCancel(l.SetRequestID(context.Background(), "req-1"))
+	groupCtx, cancel := l.DetachGroupContext(parent)
+	cancelParent()
+
+	// The same fan-out as errgroup.WithContext(groupCtx), which this module
+	// does not depend on
+	var wg sync.WaitGroup
+	for _, task := range []string{"inventory", "payment"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l.Infow(groupCtx, "task done", "task", task, "canceled", groupCtx.Err() != nil)
+		}()
+	}
+	wg.Wait()
+
+	lines := decodeLines(t, buf)
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2", len(lines))
+	}
+	for _, line := range lines {
+		if line["request_id"] != "req-1" {
+			t.Errorf("%v: request_id = %v, want req-1", line["task"], line["request_id"])
+		}
+		if line["canceled"] != false {
+			t.Errorf("%v: group context canceled with the parent", line["task"])
+		}
+	}
+
+	cancel()
+	if groupCtx.Err() == nil {
+		t.Error("group context not canceled by its cancel func")
+	}
+}
+
+func TestTenant(t *testing.T) {
+	l, buf := newLogger(t, logger.LoggerConfig{DisableTimestamp: true, DisableCaller: true})
+	ctx := l.SetTenant(l.SetUser(l.SetRequestID(context.Background(), "req-1"), "alice"), "acme")
+
+	if id, ok := l.GetTenant(ctx); !ok || id != "acme" {
+		t.Errorf("GetTenant = %q, %t, want acme, true", id, ok)
+	}
+	if _, ok := l.GetTenant(context.Background()); ok {
+		t.Error("GetTenant found a tenant in an empty context")
+	}
+
+	group, cancel := l.DetachGroupContext(ctx)
+	defer cancel()
+	tests := []struct {
+		name string
+		ctx  context.Context
+	}{
+		{"direct", ctx},
+		{"DetachContext", l.DetachContext(ctx)},
+		{"DetachGroupContext", group},
+		{"DetachContextWith", l.DetachContextWith(ctx)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf.Reset()
+			l.Info(tt.ctx, "msg")
+
+			want := []string{"level", "message", "request_id", "user", "tenant_id"}
+			if got := keyOrder(t, bytes.TrimSpace(buf.Bytes())); !slices.Equal(got, want) {
+				t.Errorf("keys = %v, want %v", got, want)
+			}
+			if got := decodeLine(t, buf)["tenant_id"]; got != "acme" {
+				t.Errorf("tenant_id = %v, want acme", got)
+			}
+		})
+	}
+}
+
+func TestNewSubRequest(t *testing.T) {
+	tests := []struct {
+		name   string
+		parent string
+		suffix string
+		want   string
+	}{
+		{"with parent", "PARENT-1", "sub", "PARENT-1.sub"},
+		{"nested", "PARENT-1.billing", "retry", "PARENT-1.billing.retry"},
+		{"without parent", "", "sub", "gen-1.sub"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, buf := newLogger(t, logger.LoggerConfig{
+				RequestIDGenerator: func() string { return "gen-1" },
+			})
+			parent := context.Background()
+			if tt.parent != "" {
+				parent = l.SetRequestID(parent, tt.parent)
+			}
+			ctx := l.NewSubRequest(parent, tt.suffix)
+
+			if id, _ := l.GetRequestID(ctx); id != tt.want {
+				t.Errorf("request ID = %q, want %q", id, tt.want)
+			}
+			if id, _ := l.GetRequestID(parent); id != tt.parent {
+				t.Errorf("parent request ID changed to %q", id)
+			}
+			l.Info(ctx, "msg")
+			if got := decodeLine(t, buf)["request_id"]; got != tt.want {
+				t.Errorf("request_id = %v, want %s", got, tt.want)
+			}
+		})
+	}
+}
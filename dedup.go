@@ -0,0 +1,131 @@
+package logger
+
+import (
+	"container/list"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// maxDedupKeys bounds the number of distinct messages tracked for
+// deduplication; the least recently seen ones are forgotten first.
+const maxDedupKeys = 1024
+
+type dedupKey struct {
+	level   zapcore.Level
+	message string
+}
+
+type dedupEntry struct {
+	key        dedupKey
+	start      time.Time
+	suppressed int
+}
+
+// dedupState is shared by a dedupCore and the cores derived from it with With.
+type dedupState struct {
+	mu      sync.Mutex
+	window  time.Duration
+	entries map[dedupKey]*list.Element
+	recent  *list.List
+}
+
+// dedupCore writes the first entry with a given level and message in each
+// window and drops the repeats. When the message recurs after the window, a
+// summary such as "msg (repeated 42 times)" is written before it. Panic and
+// Fatal entries are never dropped.
+type dedupCore struct {
+	zapcore.Core
+	state *dedupState
+	// notify, when set, is called with each summary after it is written
+	notify func(ent zapcore.Entry, fields []zapcore.Field)
+}
+
+func newDedupCore(core zapcore.Core, window time.Duration, notify func(zapcore.Entry, []zapcore.Field)) zapcore.Core {
+	return &dedupCore{
+		Core: core,
+		state: &dedupState{
+			window:  window,
+			entries: make(map[dedupKey]*list.Element),
+			recent:  list.New(),
+		},
+		notify: notify,
+	}
+}
+
+func (c *dedupCore) With(fields []zapcore.Field) zapcore.Core {
+	return &dedupCore{Core: c.Core.With(fields), state: c.state, notify: c.notify}
+}
+
+func (c *dedupCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Enabled(ent.Level) {
+		return ce
+	}
+	if ent.Level >= zapcore.DPanicLevel {
+		return c.Core.Check(ent, ce)
+	}
+
+	suppressed, ok := c.state.observe(dedupKey{ent.Level, ent.Message}, ent.Time)
+	if !ok {
+		return ce
+	}
+	if suppressed > 0 {
+		// Added first so the summary is written just before the entry, once
+		// its caller, stack and fields are known
+		ce = ce.AddCore(ent, &dedupSummaryCore{Core: c.Core, suppressed: suppressed, notify: c.notify})
+	}
+	return c.Core.Check(ent, ce)
+}
+
+// dedupSummaryCore writes the summary of the repeats dropped before an entry,
+// with the caller, stack and fields of that entry, through the wrapped core so
+// hooks see it like any other entry.
+type dedupSummaryCore struct {
+	zapcore.Core
+	suppressed int
+	notify     func(ent zapcore.Entry, fields []zapcore.Field)
+}
+
+func (c *dedupSummaryCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	ent.Message = fmt.Sprintf("%s (repeated %d times)", ent.Message, c.suppressed)
+	fields = append(slices.Clip(fields), zap.Int("repeated", c.suppressed))
+	if ce := c.Core.Check(ent, nil); ce != nil {
+		ce.Write(fields...)
+	}
+	if c.notify != nil {
+		c.notify(ent, fields)
+	}
+	return nil
+}
+
+// observe records an occurrence of key. It reports whether the entry should
+// be written and, if so, how many repeats were dropped since the last one.
+func (s *dedupState) observe(key dedupKey, now time.Time) (int, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if elem, ok := s.entries[key]; ok {
+		entry := elem.Value.(*dedupEntry)
+		s.recent.MoveToFront(elem)
+		if now.Sub(entry.start) < s.window {
+			entry.suppressed++
+			return 0, false
+		}
+		suppressed := entry.suppressed
+		entry.start = now
+		entry.suppressed = 0
+		return suppressed, true
+	}
+
+	s.entries[key] = s.recent.PushFront(&dedupEntry{key: key, start: now})
+	if s.recent.Len() > maxDedupKeys {
+		oldest := s.recent.Back()
+		s.recent.Remove(oldest)
+		delete(s.entries, oldest.Value.(*dedupEntry).key)
+	}
+	return 0, true
+}
@@ -0,0 +1,109 @@
+package logger_test
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cyrus-wg/go-logger"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestDedupWindow(t *testing.T) {
+	l, buf := newLogger(t, logger.LoggerConfig{DedupWindow: 50 * time.Millisecond, DisableStacktrace: true})
+	ctx := context.Background()
+	for range 1000 {
+		l.Error(ctx, "db unreachable")
+	}
+	l.Warn(ctx, "db unreachable")
+	time.Sleep(60 * time.Millisecond)
+	l.Error(ctx, "db unreachable")
+
+	written, repeated, warnings := 0, 0, 0
+	lines := decodeLines(t, buf)
+	for _, line := range lines {
+		msg := line["message"].(string)
+		switch {
+		case line["level"] == "WARN":
+			warnings++
+		case msg == "db unreachable":
+			written++
+		case strings.HasPrefix(msg, "db unreachable (repeated "):
+			repeated += int(line["repeated"].(float64))
+		default:
+			t.Errorf("unexpected line %v", line)
+		}
+	}
+	if len(lines) > 6 {
+		t.Errorf("got %d lines for 1002 entries, want only a few", len(lines))
+	}
+	if warnings != 1 {
+		t.Errorf("got %d Warn lines, want 1 since the level is part of the key", warnings)
+	}
+	if written+repeated != 1001 {
+		t.Errorf("%d written + %d repeated, want all 1001 Errors accounted for", written, repeated)
+	}
+	if repeated == 0 {
+		t.Error("no repeat summary was written after the window")
+	}
+}
+
+func TestDedupSummary(t *testing.T) {
+	var hooked []string
+	var notified []map[string]any
+	l, buf := newLogger(t, logger.LoggerConfig{
+		DedupWindow:       20 * time.Millisecond,
+		DisableStacktrace: true,
+		Hooks: []func(zapcore.Entry) error{func(ent zapcore.Entry) error {
+			hooked = append(hooked, ent.Message)
+			return nil
+		}},
+		OnError: func(_ context.Context, msg string, fields map[string]any) {
+			notified = append(notified, map[string]any{"message": msg, "repeated": fields["repeated"], "request_id": fields["request_id"]})
+		},
+	})
+	l = l.WithZapFields(zap.String("component", "db")).WithFields("pool", "primary")
+	ctx := l.SetRequestID(context.Background(), "req-1")
+	for range 3 {
+		l.Errorw(ctx, "db unreachable", "attempt", 1)
+	}
+	time.Sleep(30 * time.Millisecond)
+	l.Errorw(ctx, "db unreachable", "attempt", 2)
+
+	lines := decodeLines(t, buf)
+	if len(lines) != 3 {
+		t.Fatalf("got %d lines, want the first entry, the summary and the next entry:\n%s", len(lines), buf)
+	}
+	summary, next := lines[1], lines[2]
+	want := map[string]any{
+		"message":    "db unreachable (repeated 2 times)",
+		"repeated":   float64(2),
+		"request_id": "req-1",
+		"component":  "db",
+		"pool":       "primary",
+		"attempt":    float64(2),
+		"caller":     next["caller"],
+	}
+	for k, v := range want {
+		if summary[k] != v {
+			t.Errorf("summary %s = %v, want %v", k, summary[k], v)
+		}
+	}
+	if caller, _ := summary["caller"].(string); !strings.Contains(caller, "dedup_test.go") {
+		t.Errorf("summary caller = %q, want the logging call", caller)
+	}
+
+	wantMessages := []string{"db unreachable", "db unreachable (repeated 2 times)", "db unreachable"}
+	if !slices.Equal(hooked, wantMessages) {
+		t.Errorf("hooks saw %v, want %v", hooked, wantMessages)
+	}
+	if len(notified) != 3 {
+		t.Fatalf("OnError called %d times, want 3", len(notified))
+	}
+	if got := notified[1]; got["message"] != wantMessages[1] || got["repeated"] != int64(2) || got["request_id"] != "req-1" {
+		t.Errorf("OnError got summary %v, want its message, repeated count and context fields", got)
+	}
+}
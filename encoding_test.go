@@ -0,0 +1,186 @@
+package logger_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/cyrus-wg/go-logger"
+)
+
+func TestEncoding(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   logger.LoggerConfig
+		wantJSON bool
+		contains []string
+	}{
+		{"production default", logger.LoggerConfig{}, true, []string{`"message":"charge created"`, `"request_id":"req-1"`}},
+		{"development default", logger.LoggerConfig{Development: true}, false, []string{"\x1b[34mINFO\x1b[0m", "charge created", `{"request_id": "req-1"}`}},
+		{"development json", logger.LoggerConfig{Development: true, Encoding: "json"}, true, []string{`"level":"INFO"`}},
+		{"console", logger.LoggerConfig{Encoding: "console"}, false, []string{"\tcharge created\t"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			tt.config.Output = &buf
+			l, err := logger.NewLogger(tt.config)
+			if err != nil {
+				t.Fatalf("NewLogger: %v", err)
+			}
+			l.Info(l.SetRequestID(context.Background(), "req-1"), "charge created")
+
+			output := buf.String()
+			if got := json.Valid(buf.Bytes()); got != tt.wantJSON {
+				t.Errorf("JSON output = %t, want %t:\n%s", got, tt.wantJSON, output)
+			}
+			for _, want := range tt.contains {
+				if !strings.Contains(output, want) {
+					t.Errorf("output lacks %q:\n%s", want, output)
+				}
+			}
+		})
+	}
+}
+
+func TestUnknownEncoding(t *testing.T) {
+	if _, err := logger.NewLogger(logger.LoggerConfig{Encoding: "xml"}); err == nil {
+		t.Error(`NewLogger accepted Encoding "xml"`)
+	}
+}
+
+func TestEncoderKeys(t *testing.T) {
+	tests := []struct {
+		name   string
+		keys   logger.EncoderKeys
+		want   []string
+		absent []string
+	}{
+		{"default", logger.EncoderKeys{}, []string{"message", "level", "@timestamp", "caller", "stacktrace"}, nil},
+		{
+			name:   "custom",
+			keys:   logger.EncoderKeys{Message: "msg", Level: "lvl", Time: "ts", Caller: "src", Stacktrace: "stack"},
+			want:   []string{"msg", "lvl", "ts", "src", "stack"},
+			absent: []string{"message", "level", "@timestamp", "caller", "stacktrace"},
+		},
+		{"partial", logger.EncoderKeys{Message: "msg"}, []string{"msg", "level", "@timestamp"}, []string{"message"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, buf := newLogger(t, logger.LoggerConfig{EncoderKeys: tt.keys})
+			l.Error(context.Background(), "failed")
+
+			line := decodeLine(t, buf)
+			for _, k := range tt.want {
+				if _, ok := line[k]; !ok {
+					t.Errorf("missing %s in %v", k, line)
+				}
+			}
+			for _, k := range tt.absent {
+				if _, ok := line[k]; ok {
+					t.Errorf("unexpected %s in %v", k, line)
+				}
+			}
+		})
+	}
+}
+
+func TestLevelEncoding(t *testing.T) {
+	tests := []struct {
+		encoding string
+		want     string
+	}{
+		{"", "WARN"},
+		{"capital", "WARN"},
+		{"lower", "warn"},
+		{"capitalColor", "\x1b[33mWARN\x1b[0m"},
+		{"lowerColor", "\x1b[33mwarn\x1b[0m"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.encoding, func(t *testing.T) {
+			l, buf := newLogger(t, logger.LoggerConfig{LevelEncoding: tt.encoding})
+			l.Warn(context.Background(), "msg")
+
+			if got := decodeLine(t, buf)["level"]; got != tt.want {
+				t.Errorf("level = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := logger.NewLogger(logger.LoggerConfig{LevelEncoding: "upper"}); err == nil {
+		t.Error(`NewLogger accepted LevelEncoding "upper"`)
+	}
+}
+
+func TestSanitizeMessages(t *testing.T) {
+	const input = "login failed for bob\n2024-01-01T00:00:00Z\tINFO\tlogin ok for admin\r\x1b[2J"
+	tests := []struct {
+		name      string
+		config    logger.LoggerConfig
+		wantLines int
+		contains  string
+	}{
+		{"console", logger.LoggerConfig{Encoding: "console", SanitizeMessages: true}, 1, `bob\n2024-01-01T00:00:00Z\tINFO\tlogin ok for admin\r\u001b[2J`},
+		{"console unsanitized", logger.LoggerConfig{Encoding: "console"}, 2, "login ok for admin"},
+		{"json", logger.LoggerConfig{Encoding: "json", SanitizeMessages: true}, 1, `"message":"login failed for bob\\n2024`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			tt.config.Output = &buf
+			l, err := logger.NewLogger(tt.config)
+			if err != nil {
+				t.Fatalf("NewLogger: %v", err)
+			}
+			l.Info(context.Background(), input)
+
+			output := buf.String()
+			if got := strings.Count(output, "\n"); got != tt.wantLines {
+				t.Errorf("wrote %d lines, want %d:\n%s", got, tt.wantLines, output)
+			}
+			if !strings.Contains(output, tt.contains) {
+				t.Errorf("output lacks %q:\n%s", tt.contains, output)
+			}
+		})
+	}
+}
+
+func TestLogfmt(t *testing.T) {
+	tests := []struct {
+		name string
+		kv   []any
+		want string
+	}{
+		{"plain", []any{"amount", 42, "ok", true}, "amount=42 ok=true"},
+		{"spaced value", []any{"user_agent", "curl 8.5"}, `user_agent="curl 8.5"`},
+		{"quotes and equals", []any{"query", `name="a"`, "expr", "a=b"}, `query="name=\"a\"" expr="a=b"`},
+		{"newline", []any{"body", "line1\nline2"}, `body="line1\nline2"`},
+		{"empty", []any{"referer", ""}, `referer=""`},
+		{"nested object", []any{"http", map[string]any{"method": "GET", "status": 200}}, "http.method=GET http.status=200"},
+		{"array", []any{"tags", []string{"a", "b c"}}, `tags="[\"a\",\"b c\"]"`},
+		{"key with space", []any{"user id", 7}, "user_id=7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			l, err := logger.NewLogger(logger.LoggerConfig{
+				Output:           &buf,
+				Encoding:         "logfmt",
+				DisableTimestamp: true,
+				DisableCaller:    true,
+				FixedKeyValues:   map[string]any{"service": "billing api"},
+			})
+			if err != nil {
+				t.Fatalf("NewLogger: %v", err)
+			}
+			l.Infow(l.SetRequestID(context.Background(), "req-1"), "charge created", tt.kv...)
+
+			want := `level=INFO message="charge created" service="billing api" request_id=req-1 ` + tt.want + "\n"
+			if got := buf.String(); got != want {
+				t.Errorf("got  %s want %s", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package logger
+
+import (
+	"os"
+	"strconv"
+)
+
+// ConfigFromEnv returns a LoggerConfig set from the environment:
+//
+//	LOG_LEVEL   Level, e.g. "debug" or "warn" (see ParseLevel)
+//	LOG_FORMAT  Encoding, "json", "console" or "logfmt"
+//	LOG_DEV     Development, e.g. "true" or "1"
+//
+// Unset variables and values that can't be parsed are left at their zero
+// value, so the defaults apply.
+func ConfigFromEnv() LoggerConfig {
+	var config LoggerConfig
+
+	if level, err := ParseLevel(os.Getenv("LOG_LEVEL")); err == nil {
+		config.Level = level
+	}
+	switch format := os.Getenv("LOG_FORMAT"); format {
+	case jsonEncoding, consoleEncoding, logfmtEncoding:
+		config.Encoding = format
+	}
+	if dev, err := strconv.ParseBool(os.Getenv("LOG_DEV")); err == nil {
+		config.Development = dev
+	}
+
+	return config
+}
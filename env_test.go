@@ -0,0 +1,43 @@
+package logger_test
+
+import (
+	"testing"
+
+	"github.com/cyrus-wg/go-logger"
+)
+
+func TestConfigFromEnv(t *testing.T) {
+	tests := []struct {
+		name         string
+		env          map[string]string
+		wantLevel    logger.Level
+		wantEncoding string
+		wantDev      bool
+	}{
+		{"unset", nil, 0, "", false},
+		{"all set", map[string]string{"LOG_LEVEL": "WARNING", "LOG_FORMAT": "console", "LOG_DEV": "1"}, logger.WarnLevel, "console", true},
+		{"logfmt", map[string]string{"LOG_FORMAT": "logfmt"}, 0, "logfmt", false},
+		{"invalid values", map[string]string{"LOG_LEVEL": "loud", "LOG_FORMAT": "text", "LOG_DEV": "maybe"}, 0, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"LOG_LEVEL", "LOG_FORMAT", "LOG_DEV"} {
+				t.Setenv(key, tt.env[key])
+			}
+
+			config := logger.ConfigFromEnv()
+			if config.Level != tt.wantLevel {
+				t.Errorf("Level = %v, want %v", config.Level, tt.wantLevel)
+			}
+			if config.Encoding != tt.wantEncoding {
+				t.Errorf("Encoding = %q, want %q", config.Encoding, tt.wantEncoding)
+			}
+			if config.Development != tt.wantDev {
+				t.Errorf("Development = %v, want %v", config.Development, tt.wantDev)
+			}
+			if _, err := logger.NewLogger(config); err != nil {
+				t.Errorf("NewLogger(ConfigFromEnv()) = %v", err)
+			}
+		})
+	}
+}
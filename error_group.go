@@ -0,0 +1,30 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// ErrorGrouped logs err at Error level with an error_group field derived from
+// fingerprint, so a log backend can group occurrences of the same failure
+// even when their messages differ. A good fingerprint names the failure but
+// leaves out values that change between occurrences, e.g.
+// "payments.charge: card declined" rather than one containing an order ID.
+func (l *Logger) ErrorGrouped(ctx context.Context, fingerprint string, err error, msg string) {
+	l.log(ctx, zapcore.ErrorLevel, msg, errorGroupFields(fingerprint, err))
+}
+
+func errorGroupFields(fingerprint string, err error) []any {
+	return []any{zap.Error(err), "error_group", errorGroup(fingerprint)}
+}
+
+// errorGroup returns a stable hash of fingerprint as 16 hex digits.
+func errorGroup(fingerprint string) string {
+	h := fnv.New64a()
+	h.Write([]byte(fingerprint))
+	return fmt.Sprintf("%016x", h.Sum64())
+}
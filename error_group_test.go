@@ -0,0 +1,44 @@
+package logger_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/cyrus-wg/go-logger"
+)
+
+func TestErrorGrouped(t *testing.T) {
+	l, buf := newLogger(t, logger.LoggerConfig{DisableStacktrace: true})
+	ctx := context.Background()
+	l.ErrorGrouped(ctx, "payments.charge: card declined", errors.New("card 4242 declined"), "charge for order 1 failed")
+	l.ErrorGrouped(ctx, "payments.charge: card declined", errors.New("card 1881 declined"), "charge for order 2 failed")
+	l.ErrorGrouped(ctx, "payments.charge: insufficient funds", errors.New("card 4242 declined"), "charge for order 1 failed")
+
+	lines := decodeLines(t, buf)
+	if len(lines) != 3 {
+		t.Fatalf("got %d lines, want 3", len(lines))
+	}
+	groups := make([]string, len(lines))
+	for i, line := range lines {
+		groups[i], _ = line["error_group"].(string)
+		if line["error"] == nil || line["level"] != "ERROR" {
+			t.Errorf("line %d = %v, want an Error entry with the error", i, line)
+		}
+	}
+
+	tests := []struct {
+		name string
+		a, b string
+		same bool
+	}{
+		{"same fingerprint", groups[0], groups[1], true},
+		{"different fingerprint", groups[0], groups[2], false},
+		{"stable across releases", groups[0], "199b5e67ffe6ba26", true},
+	}
+	for _, tt := range tests {
+		if got := tt.a == tt.b; got != tt.same {
+			t.Errorf("%s: error_group %q == %q is %t, want %t", tt.name, tt.a, tt.b, got, tt.same)
+		}
+	}
+}
@@ -0,0 +1,9 @@
+package logger
+
+// SetHostnameFunc replaces the hostname lookup of IncludeHostname until the
+// returned function is called.
+func SetHostnameFunc(f func() (string, error)) (restore func()) {
+	orig := osHostname
+	osHostname = f
+	return func() { osHostname = orig }
+}
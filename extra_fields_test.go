@@ -0,0 +1,79 @@
+package logger_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/cyrus-wg/go-logger"
+)
+
+func TestExtraFieldsDoNotShadowBuiltInKeys(t *testing.T) {
+	l, buf := newLogger(t, logger.LoggerConfig{
+		Level:       logger.InfoLevel,
+		ExtraFields: []string{"user", "trace", "tenant"},
+	})
+
+	ctx := l.SetUser(context.Background(), "alice")
+	ctx = l.SetExtraField(ctx, "user", "bob")
+	ctx = l.SetExtraField(ctx, "trace", true)
+	ctx = l.SetExtraField(ctx, "tenant", "acme")
+
+	if user, _ := l.GetUser(ctx); user != "alice" {
+		t.Errorf("GetUser = %v, want alice", user)
+	}
+	if logger.IsTraced(ctx) {
+		t.Error("SetExtraField(ctx, \"trace\", true) enabled tracing")
+	}
+
+	l.Debug(ctx, "hidden")
+	l.Info(ctx, "shown")
+	line := decodeLine(t, buf)
+	if line["message"] != "shown" {
+		t.Errorf("message = %v, want shown", line["message"])
+	}
+	if line["user"] != "alice" {
+		t.Errorf("user = %v, want alice", line["user"])
+	}
+	if n := bytes.Count(buf.Bytes(), []byte(`"user"`)); n != 1 {
+		t.Errorf("user logged %d times, want once:\n%s", n, buf)
+	}
+	if line["tenant"] != "acme" {
+		t.Errorf("tenant = %v, want acme", line["tenant"])
+	}
+}
+
+func TestExtraFieldsSurviveDetachContext(t *testing.T) {
+	tests := []struct {
+		name  string
+		store func(l *logger.Logger, ctx context.Context) context.Context
+	}{
+		{"SetExtraField", func(l *logger.Logger, ctx context.Context) context.Context {
+			return l.SetExtraField(ctx, "tenant", "acme")
+		}},
+		{"plain string key", func(l *logger.Logger, ctx context.Context) context.Context {
+			return context.WithValue(ctx, "tenant", "acme")
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, buf := newLogger(t, logger.LoggerConfig{ExtraFields: []string{"tenant"}})
+			ctx, cancel := context.WithCancel(tt.store(l, context.Background()))
+			detached := l.DetachContext(ctx)
+			cancel()
+
+			fields, _ := l.GetExtraFields(detached)
+			if fields["tenant"] != "acme" {
+				t.Errorf("GetExtraFields(detached) = %v, want tenant=acme", fields)
+			}
+			if detached.Err() != nil {
+				t.Errorf("detached context was canceled: %v", detached.Err())
+			}
+
+			l.Info(detached, "detached")
+			if line := decodeLine(t, buf); line["tenant"] != "acme" {
+				t.Errorf("tenant = %v, want acme", line["tenant"])
+			}
+		})
+	}
+}
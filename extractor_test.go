@@ -0,0 +1,85 @@
+package logger_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/cyrus-wg/go-logger"
+)
+
+type orgKey struct{}
+
+func orgExtractor(key string) logger.FieldExtractor {
+	return func(ctx context.Context) (string, any, bool) {
+		org, ok := ctx.Value(orgKey{}).(string)
+		return key, org, ok
+	}
+}
+
+func TestFieldExtractors(t *testing.T) {
+	constant := func(key string, value any) logger.FieldExtractor {
+		return func(context.Context) (string, any, bool) { return key, value, true }
+	}
+	tests := []struct {
+		name       string
+		extractors []logger.FieldExtractor
+		multi      []logger.MultiFieldExtractor
+		ctx        context.Context
+		want       map[string]any
+		absent     []string
+	}{
+		{
+			name:       "typed key",
+			extractors: []logger.FieldExtractor{orgExtractor("org")},
+			ctx:        context.WithValue(context.Background(), orgKey{}, "acme"),
+			want:       map[string]any{"org": "acme"},
+		},
+		{
+			name:       "typed key missing",
+			extractors: []logger.FieldExtractor{orgExtractor("org")},
+			ctx:        context.Background(),
+			absent:     []string{"org"},
+		},
+		{
+			name:       "later extractor wins",
+			extractors: []logger.FieldExtractor{constant("org", "default"), orgExtractor("org"), constant("region", "eu")},
+			ctx:        context.WithValue(context.Background(), orgKey{}, "acme"),
+			want:       map[string]any{"org": "acme", "region": "eu"},
+		},
+		{
+			name:       "multi field extractor",
+			extractors: []logger.FieldExtractor{constant("org", "default")},
+			multi: []logger.MultiFieldExtractor{func(context.Context) []any {
+				return []any{"org", "acme", 7, "ignored", "region", "eu", "trailing"}
+			}},
+			ctx:    context.Background(),
+			want:   map[string]any{"org": "acme", "region": "eu"},
+			absent: []string{"7", "trailing"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, buf := newLogger(t, logger.LoggerConfig{
+				FieldExtractors:      tt.extractors,
+				MultiFieldExtractors: tt.multi,
+			})
+			l.Info(tt.ctx, "msg")
+
+			line := decodeLine(t, buf)
+			for k, v := range tt.want {
+				if line[k] != v {
+					t.Errorf("%s = %v, want %v", k, line[k], v)
+				}
+			}
+			for _, k := range tt.absent {
+				if _, ok := line[k]; ok {
+					t.Errorf("unexpected %s = %v", k, line[k])
+				}
+			}
+			if n := bytes.Count(buf.Bytes(), []byte(`"org"`)); len(tt.absent) == 0 && n != 1 {
+				t.Errorf("org logged %d times, want once", n)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package logger
+
+import (
+	"net/http"
+	"strings"
+)
+
+// RequestDetailGroup selects categories of the "Incoming request" details.
+// Groups are combined with |, e.g. BasicInfo | ClientInfo.
+type RequestDetailGroup int
+
+const (
+	// BasicInfo is the method, url, path, query_params, protocol and host.
+	BasicInfo RequestDetailGroup = 1 << iota
+	// ClientInfo is the user_ip, remote_addr, user_agent and referer.
+	ClientInfo
+	// ContentInfo is the content_length, content_type and accept headers.
+	ContentInfo
+	// SecurityHeaders is the origin header.
+	SecurityHeaders
+	// ProxyHeaders are the load balancer and proxy headers such as
+	// x_forwarded_for and x_real_ip.
+	ProxyHeaders
+
+	// AllRequestDetails is every group, the default.
+	AllRequestDetails = BasicInfo | ClientInfo | ContentInfo | SecurityHeaders | ProxyHeaders
+)
+
+// defaultHeaderGroups are the request headers logged in the "Incoming
+// request" details, by group, when MiddlewareConfig.HeaderAllowList is empty.
+var defaultHeaderGroups = []struct {
+	group   RequestDetailGroup
+	headers []string
+}{
+	{ClientInfo, []string{"User-Agent", "Referer"}},
+	{ContentInfo, []string{"Content-Type", "Accept", "Accept-Encoding", "Accept-Language"}},
+	{SecurityHeaders, []string{"Origin"}},
+	{ProxyHeaders, []string{"X-Forwarded-For", "X-Forwarded-Proto", "X-Forwarded-Host", "X-Real-IP", "X-Client-IP"}},
+}
+
+// defaultHeaderAllowList returns the default headers of the selected groups.
+func defaultHeaderAllowList(groups RequestDetailGroup) []string {
+	var allowList []string
+	for _, g := range defaultHeaderGroups {
+		if groups&g.group != 0 {
+			allowList = append(allowList, g.headers...)
+		}
+	}
+	return allowList
+}
+
+// addHeaders adds the allow-listed headers to fields under snake_case keys,
+// e.g. User-Agent as user_agent. Multiple values are joined with ", ".
+func addHeaders(fields map[string]any, header http.Header, allowList []string) {
+	for _, name := range allowList {
+		fields[headerFieldKey(name)] = strings.Join(header.Values(name), ", ")
+	}
+}
+
+func headerFieldKey(name string) string {
+	return strings.ToLower(strings.ReplaceAll(name, "-", "_"))
+}
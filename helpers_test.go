@@ -0,0 +1,59 @@
+package logger_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/cyrus-wg/go-logger"
+	"github.com/cyrus-wg/go-logger/loggertest"
+)
+
+// newLogger returns a logger built from config that writes JSON lines to the
+// returned buffer, failing the test if config is invalid.
+func newLogger(t *testing.T, config logger.LoggerConfig) (*logger.Logger, *bytes.Buffer) {
+	t.Helper()
+	l, buf, err := loggertest.NewLoggerWithBufferConfig(config)
+	if err != nil {
+		t.Fatalf("NewLogger: %v", err)
+	}
+	return l, buf
+}
+
+// decodeLines unmarshals every JSON line written to buf.
+func decodeLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var lines []map[string]any
+	scanner := bufio.NewScanner(bytes.NewReader(buf.Bytes()))
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var line map[string]any
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("invalid JSON line %q: %v", scanner.Text(), err)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// decodeLine unmarshals the single JSON line written to buf.
+func decodeLine(t *testing.T, buf *bytes.Buffer) map[string]any {
+	t.Helper()
+	lines := decodeLines(t, buf)
+	if len(lines) != 1 {
+		t.Fatalf("got %d lines, want 1:\n%s", len(lines), buf)
+	}
+	return lines[0]
+}
+
+// messages returns the message of every JSON line written to buf.
+func messages(t *testing.T, buf *bytes.Buffer) []string {
+	t.Helper()
+	var msgs []string
+	for _, line := range decodeLines(t, buf) {
+		msg, _ := line["message"].(string)
+		msgs = append(msgs, msg)
+	}
+	return msgs
+}
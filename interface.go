@@ -0,0 +1,25 @@
+package logger
+
+import "context"
+
+// LoggerInterface is the logging method set of *Logger, for code that wants
+// to accept a fake in tests. Methods are only added to it in a new major
+// version, so implementations outside this package keep compiling.
+type LoggerInterface interface {
+	Debug(ctx context.Context, args ...any)
+	Info(ctx context.Context, args ...any)
+	Warn(ctx context.Context, args ...any)
+	Error(ctx context.Context, args ...any)
+
+	Debugf(ctx context.Context, template string, args ...any)
+	Infof(ctx context.Context, template string, args ...any)
+	Warnf(ctx context.Context, template string, args ...any)
+	Errorf(ctx context.Context, template string, args ...any)
+
+	Debugw(ctx context.Context, msg string, keysAndValues ...any)
+	Infow(ctx context.Context, msg string, keysAndValues ...any)
+	Warnw(ctx context.Context, msg string, keysAndValues ...any)
+	Errorw(ctx context.Context, msg string, keysAndValues ...any)
+}
+
+var _ LoggerInterface = (*Logger)(nil)
@@ -0,0 +1,43 @@
+package logger_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/cyrus-wg/go-logger"
+)
+
+// recordingLogger is a fake LoggerInterface that records Errorw messages.
+type recordingLogger struct {
+	logger.LoggerInterface
+	errors []string
+}
+
+func (r *recordingLogger) Errorw(ctx context.Context, msg string, keysAndValues ...any) {
+	r.errors = append(r.errors, msg)
+}
+
+// chargeCustomer stands in for downstream code that accepts the interface.
+func chargeCustomer(ctx context.Context, log logger.LoggerInterface, amount int) {
+	if amount <= 0 {
+		log.Errorw(ctx, "invalid amount", "amount", amount)
+	}
+}
+
+func TestLoggerInterface(t *testing.T) {
+	ctx := context.Background()
+
+	fake := &recordingLogger{}
+	chargeCustomer(ctx, fake, 0)
+	chargeCustomer(ctx, fake, 10)
+	if want := []string{"invalid amount"}; !slices.Equal(fake.errors, want) {
+		t.Errorf("fake recorded %v, want %v", fake.errors, want)
+	}
+
+	l, buf := newLogger(t, logger.LoggerConfig{DisableStacktrace: true})
+	chargeCustomer(ctx, l, 0)
+	if line := decodeLine(t, buf); line["message"] != "invalid amount" || line["amount"] != float64(0) {
+		t.Errorf("*Logger logged %v, want invalid amount with amount 0", line)
+	}
+}
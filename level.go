@@ -0,0 +1,110 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Level is a logging priority. The zero value means "not set", in which case
+// the level is derived from LoggerConfig.Development.
+type Level int8
+
+const (
+	DebugLevel Level = iota + 1
+	InfoLevel
+	WarnLevel
+	ErrorLevel
+	PanicLevel
+	FatalLevel
+)
+
+var levelToZap = map[Level]zapcore.Level{
+	DebugLevel: zapcore.DebugLevel,
+	InfoLevel:  zapcore.InfoLevel,
+	WarnLevel:  zapcore.WarnLevel,
+	ErrorLevel: zapcore.ErrorLevel,
+	PanicLevel: zapcore.PanicLevel,
+	FatalLevel: zapcore.FatalLevel,
+}
+
+func (lv Level) String() string {
+	if zl, ok := levelToZap[lv]; ok {
+		return zl.String()
+	}
+	return fmt.Sprintf("Level(%d)", lv)
+}
+
+func (lv Level) zapLevel() (zapcore.Level, error) {
+	zl, ok := levelToZap[lv]
+	if !ok {
+		return zapcore.InvalidLevel, fmt.Errorf("logger: unknown level %d", lv)
+	}
+	return zl, nil
+}
+
+func levelFromZap(zl zapcore.Level) Level {
+	for lv, z := range levelToZap {
+		if z == zl {
+			return lv
+		}
+	}
+	return 0
+}
+
+// ParseLevel parses a level name such as "debug" or "WARN", ignoring case.
+// "warning" is accepted as an alias of "warn".
+func ParseLevel(s string) (Level, error) {
+	name := strings.ToLower(strings.TrimSpace(s))
+	if name == "warning" {
+		return WarnLevel, nil
+	}
+	for lv, z := range levelToZap {
+		if z.String() == name {
+			return lv, nil
+		}
+	}
+	return 0, fmt.Errorf("logger: unknown level %q", s)
+}
+
+type levelPayload struct {
+	Level string `json:"level"`
+}
+
+// LevelHandler returns an HTTP handler that reports the current level on GET
+// and changes it on PUT or POST with a JSON body such as {"level":"debug"}.
+func (l *Logger) LevelHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut, http.MethodPost:
+			var payload levelPayload
+			if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+				writeLevelError(w, http.StatusBadRequest, "invalid request body")
+				return
+			}
+			level, err := ParseLevel(payload.Level)
+			if err != nil {
+				writeLevelError(w, http.StatusBadRequest, fmt.Sprintf("unknown level %q", payload.Level))
+				return
+			}
+			l.SetLevel(level)
+		default:
+			w.Header().Set("Allow", "GET, PUT, POST")
+			writeLevelError(w, http.StatusMethodNotAllowed, "only GET, PUT and POST are supported")
+			return
+		}
+
+		json.NewEncoder(w).Encode(levelPayload{Level: l.GetLevel().String()})
+	})
+}
+
+func writeLevelError(w http.ResponseWriter, status int, msg string) {
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": msg})
+}
@@ -0,0 +1,226 @@
+package logger_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/cyrus-wg/go-logger"
+)
+
+func TestConfigLevel(t *testing.T) {
+	tests := []struct {
+		name   string
+		config logger.LoggerConfig
+		want   []string
+	}{
+		{"production default", logger.LoggerConfig{}, []string{"info", "warn"}},
+		{"development default", logger.LoggerConfig{Development: true}, []string{"debug", "info", "warn"}},
+		{"level", logger.LoggerConfig{Level: logger.WarnLevel}, []string{"warn"}},
+		{"level over development", logger.LoggerConfig{Development: true, Level: logger.InfoLevel}, []string{"info", "warn"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			tt.config.Output = &buf
+			tt.config.Encoding = "json"
+			l, err := logger.NewLogger(tt.config)
+			if err != nil {
+				t.Fatalf("NewLogger: %v", err)
+			}
+
+			ctx := context.Background()
+			l.Debug(ctx, "debug")
+			l.Info(ctx, "info")
+			l.Warn(ctx, "warn")
+
+			if got := messages(t, &buf); !slices.Equal(got, tt.want) {
+				t.Errorf("logged %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigUnknownLevel(t *testing.T) {
+	if _, err := logger.NewLogger(logger.LoggerConfig{Level: logger.Level(42)}); err == nil {
+		t.Error("NewLogger accepted Level(42)")
+	}
+}
+
+func TestSetLevel(t *testing.T) {
+	l, buf := newLogger(t, logger.LoggerConfig{Level: logger.InfoLevel})
+	ctx := context.Background()
+
+	l.Debug(ctx, "before")
+	l.SetLevel(logger.DebugLevel)
+	if got := l.GetLevel(); got != logger.DebugLevel {
+		t.Errorf("GetLevel = %v, want debug", got)
+	}
+	l.Debug(ctx, "after")
+	l.SetLevel(logger.ErrorLevel)
+	l.Warn(ctx, "filtered")
+
+	if got, want := messages(t, buf), []string{"after"}; !slices.Equal(got, want) {
+		t.Errorf("logged %v, want %v", got, want)
+	}
+}
+
+func TestSetLevelConcurrent(t *testing.T) {
+	l, _ := newLogger(t, logger.LoggerConfig{})
+	var wg sync.WaitGroup
+	for i := range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				if i%2 == 0 {
+					l.SetLevel(logger.InfoLevel)
+				} else {
+					l.Info(context.Background(), "msg")
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestLevelHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		body       string
+		wantStatus int
+		wantBody   string
+		wantLevel  logger.Level
+	}{
+		{"get", http.MethodGet, "", http.StatusOK, `{"level":"info"}`, logger.InfoLevel},
+		{"put", http.MethodPut, `{"level":"debug"}`, http.StatusOK, `{"level":"debug"}`, logger.DebugLevel},
+		{"post warning alias", http.MethodPost, `{"level":"WARNING"}`, http.StatusOK, `{"level":"warn"}`, logger.WarnLevel},
+		{"unknown level", http.MethodPut, `{"level":"loud"}`, http.StatusBadRequest, `{"error":"unknown level \"loud\""}`, logger.InfoLevel},
+		{"invalid body", http.MethodPut, `level=debug`, http.StatusBadRequest, `{"error":"invalid request body"}`, logger.InfoLevel},
+		{"method not allowed", http.MethodDelete, "", http.StatusMethodNotAllowed, `{"error":"only GET, PUT and POST are supported"}`, logger.InfoLevel},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, _ := newLogger(t, logger.LoggerConfig{Level: logger.InfoLevel})
+			rec := httptest.NewRecorder()
+			l.LevelHandler().ServeHTTP(rec, httptest.NewRequest(tt.method, "/loglevel", strings.NewReader(tt.body)))
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := strings.TrimSpace(rec.Body.String()); got != tt.wantBody {
+				t.Errorf("body = %s, want %s", got, tt.wantBody)
+			}
+			if got := l.GetLevel(); got != tt.wantLevel {
+				t.Errorf("level = %v, want %v", got, tt.wantLevel)
+			}
+		})
+	}
+}
+
+func TestLevelEnabled(t *testing.T) {
+	l, _ := newLogger(t, logger.LoggerConfig{Level: logger.InfoLevel})
+	tests := []struct {
+		level logger.Level
+		want  bool
+	}{
+		{logger.DebugLevel, false},
+		{logger.InfoLevel, true},
+		{logger.ErrorLevel, true},
+		{logger.Level(42), false},
+	}
+	for _, tt := range tests {
+		if got := l.LevelEnabled(tt.level); got != tt.want {
+			t.Errorf("LevelEnabled(%v) = %t, want %t", tt.level, got, tt.want)
+		}
+	}
+
+	if l.DebugEnabled() {
+		t.Error("DebugEnabled at Info level")
+	}
+	l.SetLevel(logger.DebugLevel)
+	if !l.DebugEnabled() {
+		t.Error("DebugEnabled false after SetLevel(DebugLevel)")
+	}
+}
+
+func TestEnableTrace(t *testing.T) {
+	l, buf := newLogger(t, logger.LoggerConfig{Level: logger.InfoLevel})
+	handler := l.LoggerMiddleware(false, false)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if r.Header.Get("X-Debug") != "" {
+			ctx = l.EnableTrace(ctx)
+		}
+		l.Debugw(ctx, "cache lookup", "path", r.URL.Path)
+		l.WithFields("component", "db").Debugw(ctx, "query", "path", r.URL.Path)
+		l.Debugw(l.DetachContext(ctx), "background job", "path", r.URL.Path)
+		l.Infow(ctx, "handled", "path", r.URL.Path)
+	}))
+
+	tests := []struct {
+		path   string
+		traced bool
+		want   []string
+	}{
+		{"/plain", false, []string{"handled"}},
+		{"/traced", true, []string{"cache lookup", "query", "background job", "handled"}},
+		{"/plain-again", false, []string{"handled"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			buf.Reset()
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.traced {
+				req.Header.Set("X-Debug", "1")
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			if got := messages(t, buf); !slices.Equal(got, tt.want) {
+				t.Errorf("logged %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if got := l.GetLevel(); got != logger.InfoLevel {
+		t.Errorf("level = %v after a traced request, want info", got)
+	}
+}
+
+func TestWithTemporaryLevel(t *testing.T) {
+	tests := []struct {
+		name      string
+		temporary logger.Level
+		want      []string
+	}{
+		{"lower", logger.DebugLevel, []string{"inside debug", "inside info", "after info"}},
+		{"higher", logger.ErrorLevel, []string{"after info"}},
+		{"unknown", logger.Level(42), []string{"inside info", "after info"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, buf := newLogger(t, logger.LoggerConfig{Level: logger.InfoLevel})
+			child := l.WithFields("component", "billing")
+			ctx := context.Background()
+
+			func() {
+				defer l.WithTemporaryLevel(tt.temporary)()
+				child.Debug(ctx, "inside debug")
+				child.Info(ctx, "inside info")
+			}()
+			if got := l.GetLevel(); got != logger.InfoLevel {
+				t.Errorf("level after restore = %v, want info", got)
+			}
+			child.Debug(ctx, "after debug")
+			child.Info(ctx, "after info")
+
+			if got := messages(t, buf); !slices.Equal(got, tt.want) {
+				t.Errorf("logged %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
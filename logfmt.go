@@ -0,0 +1,126 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+var logfmtPool = buffer.NewPool()
+
+// logfmtEncoder writes entries as space-separated key=value pairs. It encodes
+// each entry with the JSON encoder first, so every EncoderConfig option
+// applies exactly as for "json", and then rewrites the object: nested
+// objects are flattened into dotted keys, arrays are kept as quoted JSON and
+// values with spaces, quotes or control characters are quoted.
+type logfmtEncoder struct {
+	zapcore.Encoder
+	lineEnding string
+}
+
+func newLogfmtEncoder(encoderConfig zapcore.EncoderConfig) zapcore.Encoder {
+	lineEnding := encoderConfig.LineEnding
+	if lineEnding == "" {
+		lineEnding = zapcore.DefaultLineEnding
+	}
+	encoderConfig.LineEnding = "\n"
+	return &logfmtEncoder{Encoder: zapcore.NewJSONEncoder(encoderConfig), lineEnding: lineEnding}
+}
+
+func (e *logfmtEncoder) Clone() zapcore.Encoder {
+	return &logfmtEncoder{Encoder: e.Encoder.Clone(), lineEnding: e.lineEnding}
+}
+
+func (e *logfmtEncoder) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	encoded, err := e.Encoder.EncodeEntry(entry, fields)
+	if err != nil {
+		return nil, err
+	}
+	defer encoded.Free()
+
+	out := logfmtPool.Get()
+	if err := appendLogfmt(out, "", encoded.Bytes()); err != nil {
+		out.Free()
+		return nil, err
+	}
+	out.AppendString(e.lineEnding)
+	return out, nil
+}
+
+// appendLogfmt appends the members of the JSON object in data as key=value
+// pairs, prefixing each key with prefix.
+func appendLogfmt(out *buffer.Buffer, prefix string, data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if _, err := dec.Token(); err != nil { // {
+		return err
+	}
+
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key := prefix + logfmtKey(token.(string))
+
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return err
+		}
+
+		switch raw[0] {
+		case '{':
+			if err := appendLogfmt(out, key+".", raw); err != nil {
+				return err
+			}
+			continue
+		case '"':
+			var s string
+			if err := json.Unmarshal(raw, &s); err != nil {
+				return err
+			}
+			appendLogfmtPair(out, key, s)
+		default:
+			// Numbers, booleans, null and arrays as compact JSON
+			appendLogfmtPair(out, key, string(raw))
+		}
+	}
+	return nil
+}
+
+func appendLogfmtPair(out *buffer.Buffer, key, value string) {
+	if out.Len() > 0 {
+		out.AppendByte(' ')
+	}
+	out.AppendString(key)
+	out.AppendByte('=')
+	if needsLogfmtQuotes(value) {
+		out.AppendString(strconv.Quote(value))
+	} else {
+		out.AppendString(value)
+	}
+}
+
+func needsLogfmtQuotes(value string) bool {
+	if value == "" {
+		return true
+	}
+	return strings.ContainsFunc(value, func(r rune) bool {
+		return r == ' ' || r == '=' || r == '"' || unicode.IsControl(r) || !unicode.IsPrint(r)
+	})
+}
+
+// logfmtKey replaces the characters a logfmt key can't contain with '_'.
+func logfmtKey(key string) string {
+	return strings.Map(func(r rune) rune {
+		if r == ' ' || r == '=' || r == '"' || unicode.IsControl(r) {
+			return '_'
+		}
+		return r
+	}, key)
+}
@@ -2,195 +2,983 @@ package logger
 
 import (
 	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"maps"
 	"net"
 	"net/http"
+	"os"
 	"regexp"
+	"runtime/debug"
+	"slices"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/multierr"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
 type contextKey string
 
+// extraFieldKey is the context key of a value stored with SetExtraField. It
+// is a distinct type so an extra field named like a built-in key, such as
+// "user" or "trace", can't overwrite that value.
+type extraFieldKey string
+
 const (
 	requestIdKey contextKey = "request_id"
 	userKey      contextKey = "user"
+	userIPKey    contextKey = "user_ip"
+	tenantKey    contextKey = "tenant_id"
+	workerIDKey  contextKey = "worker_id"
+	loggerKey    contextKey = "logger"
+	traceKey     contextKey = "trace"
+
+	requestScopeKey contextKey = "request_scope"
+	fieldsKey       contextKey = "appended_fields"
 )
 
 const (
 	requestIdContextKey = string(requestIdKey)
 	userContextKey      = string(userKey)
+	userIPContextKey    = string(userIPKey)
+	tenantContextKey    = string(tenantKey)
+	workerIDContextKey  = string(workerIDKey)
+	traceIDContextKey   = "trace_id"
+	spanIDContextKey    = "span_id"
+	deadlineContextKey  = "deadline_remaining_ms"
 )
 
 type LoggerConfig struct {
+	Name            string // Root logger name, emitted under the "logger" key
 	Development     bool
-	RequestIDPrefix string
+	Level           Level  // Takes precedence over Development for level selection when set
+	RequestIDPrefix string // Prepended to every generated request ID, leave empty for none
 	FixedKeyValues  map[string]any
 	ExtraFields     []string
+	Output          io.Writer       // Destination for log output, stderr if no destination is configured
+	RotationConfig  *RotationConfig // Write to a rotating log file instead of Output
+	OutputPaths     []string        // Additional paths or URLs opened with zap.Open, e.g. "stdout" or "/var/log/app.log"
+	Sinks           []SinkConfig    // Additional writers, each with an optional minimum level and encoding
+	SplitOutput     bool            // Write Debug and Info to stdout and Warn and above to stderr instead of Output
+	Sampling        *SamplingConfig // Defaults to zap's production sampling (100/100)
+	TimeFormat      string          // time.Format layout for timestamps, defaults to ISO8601
+	TimeZone        *time.Location  // Location for timestamps, defaults to local time
+
+	// IncludeHostname and IncludeProcessID add the "hostname" and "pid"
+	// fixed fields, resolved once at construction, unless FixedKeyValues
+	// already sets them. The hostname is "unknown" if it can't be resolved.
+	IncludeHostname  bool
+	IncludeProcessID bool
+
+	// IncludeFunctionName adds the fully qualified name of the calling
+	// function under the "function" key, next to the file:line caller.
+	IncludeFunctionName bool
+
+	// DisableTimestamp and DisableCaller omit the timestamp and caller
+	// fields, e.g. when journald already records them.
+	DisableTimestamp bool
+	DisableCaller    bool
+
+	// EncoderKeys renames the message, level, time, caller and stacktrace
+	// fields, e.g. to match an ingestion schema.
+	EncoderKeys EncoderKeys
+
+	// Encoding is "json", "console" or "logfmt" (key=value pairs, nested
+	// objects flattened into dotted keys). It defaults to "console" (human
+	// readable, colored levels) in Development and "json" otherwise.
+	Encoding string
+
+	// LevelEncoding is "capital" (INFO), "lower" (info), "capitalColor" or
+	// "lowerColor". It defaults to "capital", colored in console encoding.
+	LevelEncoding string
+
+	// Async buffers writes in memory and flushes them when the buffer is full,
+	// every AsyncFlushInterval and on Flush, so logging does not block on slow
+	// sinks. Entries still buffered are lost if the process crashes before a
+	// flush; Panic and Fatal entries flush immediately. Defaults to a 256 kB
+	// buffer flushed every 30 seconds.
+	Async              bool
+	AsyncBufferSize    int
+	AsyncFlushInterval time.Duration
+
+	// DedupWindow, when set, writes an entry with a given level and message
+	// at most once per window. Repeats are dropped and their count is logged
+	// as "msg (repeated N times)" when the message next occurs. The summary
+	// has the caller and fields of that next entry and is passed to Hooks and
+	// OnError like other entries; OnError receives context.Background() for
+	// it, as the context of the call is not known where it is written.
+	DedupWindow time.Duration
+
+	// StacktraceLevel is the lowest level at which stack traces are attached,
+	// defaulting to ErrorLevel. DisableStacktrace turns them off entirely.
+	StacktraceLevel   Level
+	DisableStacktrace bool
+
+	// EnableTraceContext adds the trace_id and span_id of the active
+	// OpenTelemetry span in the context to every log line.
+	EnableTraceContext bool
+
+	// IncludeDeadline adds the time left before the context deadline, in
+	// milliseconds, as "deadline_remaining_ms" when the context has one. It
+	// is negative once the deadline has passed.
+	IncludeDeadline bool
+
+	// FieldExtractors derive additional fields from the context, e.g. values
+	// stored under typed keys. They run in order, and when several return the
+	// same key the last one wins.
+	FieldExtractors []FieldExtractor
+
+	// MultiFieldExtractors derive a varying set of fields from the context,
+	// e.g. one per baggage member. They run after FieldExtractors, and a key
+	// they return replaces the same key from an earlier extractor.
+	MultiFieldExtractors []MultiFieldExtractor
+
+	// RedactKeys lists field keys, matched case-insensitively, whose values
+	// are replaced with "[REDACTED]". Set Redactor to mask them differently.
+	RedactKeys []string
+	Redactor   Redactor
+
+	// SanitizeMessages escapes line breaks and other control characters in
+	// messages, e.g. "\n" instead of a newline, so logged user input can't
+	// forge extra lines. The JSON encoder already escapes them, so this
+	// matters mostly for console encoding. Field values are not changed.
+	SanitizeMessages bool
+
+	// MaxFieldBytes, when positive, truncates string and []byte field values
+	// longer than it, appending "...[truncated N bytes]". Truncated []byte
+	// values are logged as strings. Other values are left alone.
+	MaxFieldBytes int
+
+	// StrictFields drops key-value pairs with a missing value or a non-string
+	// key from an entry and reports them in a separate "Ignored invalid log
+	// fields" warning. A bare error is accepted like zap does, logged under
+	// "error". It is on by default in Development; DisableStrictFields turns
+	// it off even there.
+	StrictFields        bool
+	DisableStrictFields bool
+
+	// RequestIDGenerator, when set, replaces the random UUID of generated
+	// request IDs, e.g. with a ULID.
+	RequestIDGenerator func() string
+
+	// RequestIDValidator decides whether an inbound request ID, from a
+	// header or gRPC metadata, is reused. Rejected IDs are replaced by a
+	// generated one and noted in a Debug entry. By default IDs of up to 128
+	// letters, digits and "-_.:+/=" are accepted.
+	RequestIDValidator func(id string) bool
+
+	// UserFormatter, when set, converts the user stored with SetUser before
+	// it is logged, e.g. reducing a user struct to its ID and role.
+	UserFormatter func(user any) any
+
+	// LatencyObserver, when set, is called by the middleware for every
+	// completed request with its path, latency and the trace ID of the
+	// active OpenTelemetry span (empty without one), e.g. to record a
+	// histogram with trace exemplars.
+	LatencyObserver func(path string, seconds float64, traceID string)
+
+	// WrapCore, when set, wraps or replaces the zap core built from this
+	// config, e.g. to tee output into an additional core.
+	WrapCore func(zapcore.Core) zapcore.Core
+
+	// Hooks are called for every entry that is written, e.g. to count log
+	// lines by level. They receive the entry but not its fields.
+	Hooks []func(zapcore.Entry) error
+
+	// OnError is called after an entry at Error, Panic or Fatal level is
+	// written, e.g. to report it to an error tracker. It runs synchronously
+	// and a panic inside it is recovered and ignored.
+	OnError func(ctx context.Context, msg string, fields map[string]any)
+}
+
+// EncoderKeys renames the standard fields of a log line. Empty fields keep
+// their defaults: "message", "level", "@timestamp", "caller" and "stacktrace".
+type EncoderKeys struct {
+	Message    string
+	Level      string
+	Time       string
+	Caller     string
+	Stacktrace string
+}
+
+func (k EncoderKeys) applyTo(encoderConfig *zapcore.EncoderConfig) {
+	if k.Message != "" {
+		encoderConfig.MessageKey = k.Message
+	}
+	if k.Level != "" {
+		encoderConfig.LevelKey = k.Level
+	}
+	if k.Time != "" {
+		encoderConfig.TimeKey = k.Time
+	}
+	if k.Caller != "" {
+		encoderConfig.CallerKey = k.Caller
+	}
+	if k.Stacktrace != "" {
+		encoderConfig.StacktraceKey = k.Stacktrace
+	}
+}
+
+// SamplingConfig limits repeated log lines. Within each second, the first
+// Initial entries with the same level and message are logged, then every
+// Thereafter-th entry. Sampling applies to every enabled level.
+type SamplingConfig struct {
+	Initial    int
+	Thereafter int
+}
+
+// FieldExtractor returns a field to add to a log line from ctx, or ok=false to
+// add nothing.
+type FieldExtractor func(ctx context.Context) (key string, value any, ok bool)
+
+// MultiFieldExtractor returns key-value pairs to add to a log line from ctx,
+// or nil to add nothing.
+type MultiFieldExtractor func(ctx context.Context) (keysAndValues []any)
+
+// RotationConfig configures writing logs to a file that is rotated by size.
+type RotationConfig struct {
+	Filename   string
+	MaxSizeMB  int // Maximum size before rotation, defaults to 100 MB
+	MaxBackups int // Maximum number of old files to retain, 0 retains all
+	MaxAgeDays int // Maximum days to retain old files, 0 disables age-based removal
+	Compress   bool
 }
 
 type Logger struct {
-	logger          *zap.SugaredLogger
-	requestIDPrefix string
-	fixedKeyValues  map[string]any
-	extraFields     []string
-	devMode         bool
+	base               *zap.Logger
+	logger             *zap.SugaredLogger
+	traceBase          *zap.Logger
+	traceLogger        *zap.SugaredLogger
+	level              zap.AtomicLevel
+	requestIDPrefix    string
+	fixedKeyValues     *fixedKeyValues
+	extraFields        []string
+	fields             []any
+	groups             []string
+	extractors         []FieldExtractor
+	multiExtractors    []MultiFieldExtractor
+	redactKeys         map[string]struct{}
+	redactor           Redactor
+	devMode            bool
+	traceContext       bool
+	includeDeadline    bool
+	onError            func(ctx context.Context, msg string, fields map[string]any)
+	userFormatter      func(user any) any
+	requestIDGenerator func() string
+	requestIDValidator func(id string) bool
+	close              func() error
+	rotate             func() error
+	rateLimiters       *rateLimiters
+	strictFields       bool
+	latencyObserver    func(path string, seconds float64, traceID string)
+	sanitizeMessages   bool
+	maxFieldBytes      int
+}
+
+// fixedKeyValues holds the fields added to every log line. It is shared by a
+// logger and its children and may be changed while they are logging.
+type fixedKeyValues struct {
+	mu     sync.RWMutex
+	values map[string]any
+}
+
+func newFixedKeyValues(values map[string]any) *fixedKeyValues {
+	return &fixedKeyValues{values: maps.Clone(values)}
+}
+
+func (f *fixedKeyValues) set(key string, value any) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.values == nil {
+		f.values = make(map[string]any)
+	}
+	f.values[key] = value
+}
+
+// setDefault sets key unless it already has a value.
+func (f *fixedKeyValues) setDefault(key string, value any) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.values[key]; ok {
+		return
+	}
+	if f.values == nil {
+		f.values = make(map[string]any)
+	}
+	f.values[key] = value
+}
+
+func (f *fixedKeyValues) remove(key string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.values, key)
+}
+
+func (f *fixedKeyValues) clone() *fixedKeyValues {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return newFixedKeyValues(f.values)
+}
+
+func (f *fixedKeyValues) appendTo(combined []any) []any {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	for _, k := range slices.Sorted(maps.Keys(f.values)) {
+		combined = append(combined, k, f.values[k])
+	}
+	return combined
 }
 
 func NewLogger(config LoggerConfig) (*Logger, error) {
 	logger := &Logger{
-		requestIDPrefix: config.RequestIDPrefix,
-		extraFields:     config.ExtraFields,
-		devMode:         config.Development,
-		fixedKeyValues:  config.FixedKeyValues,
+		requestIDPrefix:    config.RequestIDPrefix,
+		extraFields:        config.ExtraFields,
+		devMode:            config.Development,
+		fixedKeyValues:     newFixedKeyValues(config.FixedKeyValues),
+		traceContext:       config.EnableTraceContext,
+		includeDeadline:    config.IncludeDeadline,
+		extractors:         config.FieldExtractors,
+		multiExtractors:    config.MultiFieldExtractors,
+		redactKeys:         newRedactKeySet(config.RedactKeys),
+		redactor:           config.Redactor,
+		onError:            config.OnError,
+		userFormatter:      config.UserFormatter,
+		requestIDGenerator: config.RequestIDGenerator,
+		requestIDValidator: config.RequestIDValidator,
+		rateLimiters:       newRateLimiters(),
+		strictFields:       (config.StrictFields || config.Development) && !config.DisableStrictFields,
+		latencyObserver:    config.LatencyObserver,
+		sanitizeMessages:   config.SanitizeMessages,
+		maxFieldBytes:      config.MaxFieldBytes,
+	}
+
+	if config.IncludeHostname {
+		hostname, err := osHostname()
+		if err != nil {
+			hostname = "unknown"
+		}
+		logger.fixedKeyValues.setDefault("hostname", hostname)
+	}
+	if config.IncludeProcessID {
+		logger.fixedKeyValues.setDefault("pid", os.Getpid())
 	}
 
 	loggerConfig := zap.NewProductionConfig()
 	if logger.devMode {
 		loggerConfig.Level = zap.NewAtomicLevelAt(zap.DebugLevel)
 	}
+	if config.Level != 0 {
+		level, err := config.Level.zapLevel()
+		if err != nil {
+			return nil, err
+		}
+		loggerConfig.Level = zap.NewAtomicLevelAt(level)
+	}
+
+	if config.Sampling != nil {
+		loggerConfig.Sampling = &zap.SamplingConfig{
+			Initial:    config.Sampling.Initial,
+			Thereafter: config.Sampling.Thereafter,
+		}
+	}
 
 	loggerConfig.EncoderConfig.EncodeLevel = zapcore.CapitalLevelEncoder
 	loggerConfig.EncoderConfig.MessageKey = "message"
 	loggerConfig.EncoderConfig.TimeKey = "@timestamp"
+	config.EncoderKeys.applyTo(&loggerConfig.EncoderConfig)
 	loggerConfig.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+	if config.IncludeFunctionName {
+		loggerConfig.EncoderConfig.FunctionKey = "function"
+	}
+	if config.DisableTimestamp {
+		loggerConfig.EncoderConfig.TimeKey = zapcore.OmitKey
+	}
+	if config.TimeFormat != "" || config.TimeZone != nil {
+		loggerConfig.EncoderConfig.EncodeTime = timeEncoder(config.TimeFormat, config.TimeZone)
+	}
 
-	zLogger, err := loggerConfig.Build(
-		zap.AddCallerSkip(1),
-	)
+	encoder, err := newEncoder(config, loggerConfig.EncoderConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	core, controls, err := buildCore(config, encoder, loggerConfig.EncoderConfig, zapcore.DebugLevel)
 	if err != nil {
 		return nil, err
 	}
+	// Hooks wrap the core inside deduplication so they also see its summaries
+	if len(config.Hooks) > 0 {
+		core = zapcore.RegisterHooks(core, config.Hooks...)
+	}
+	if config.DedupWindow > 0 {
+		var notify func(zapcore.Entry, []zapcore.Field)
+		if config.OnError != nil {
+			notify = func(ent zapcore.Entry, fields []zapcore.Field) {
+				if ent.Level >= zapcore.ErrorLevel {
+					logger.notifyError(context.Background(), ent.Message, fieldsToAttributes(fields))
+				}
+			}
+		}
+		core = newDedupCore(core, config.DedupWindow, notify)
+	}
+	if sampling := loggerConfig.Sampling; sampling != nil {
+		core = zapcore.NewSamplerWithOptions(core, time.Second, sampling.Initial, sampling.Thereafter)
+	}
+	if config.WrapCore != nil {
+		core = config.WrapCore(core)
+	}
+
+	options := []zap.Option{
+		zap.ErrorOutput(zapcore.Lock(os.Stderr)),
+		zap.WithCaller(!config.DisableCaller),
+		zap.AddCallerSkip(2), // The public method and Logger.log
+	}
+	if !config.DisableStacktrace {
+		stacktraceLevel := zapcore.ErrorLevel
+		if config.StacktraceLevel != 0 {
+			if stacktraceLevel, err = config.StacktraceLevel.zapLevel(); err != nil {
+				return nil, err
+			}
+		}
+		options = append(options, zap.AddStacktrace(stacktraceLevel))
+	}
+
+	// The core is enabled at every level so traced contexts can log at Debug;
+	// the regular logger filters by the configured level on top of it
+	traceBase := zap.New(core, options...)
+	if config.Name != "" {
+		traceBase = traceBase.Named(config.Name)
+	}
 
-	logger.logger = zLogger.Sugar()
+	logger.setBase(traceBase.WithOptions(zap.IncreaseLevel(loggerConfig.Level)), traceBase)
+	logger.level = loggerConfig.Level
+	logger.close = controls.close
+	logger.rotate = controls.rotate
 	return logger, nil
 }
 
+const (
+	jsonEncoding    = "json"
+	consoleEncoding = "console"
+	logfmtEncoding  = "logfmt"
+)
+
+func newEncoder(config LoggerConfig, encoderConfig zapcore.EncoderConfig) (zapcore.Encoder, error) {
+	encoding := config.Encoding
+	if encoding == "" {
+		encoding = jsonEncoding
+		if config.Development {
+			encoding = consoleEncoding
+		}
+	}
+
+	switch encoding {
+	case jsonEncoding, logfmtEncoding:
+	case consoleEncoding:
+		encoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
+	default:
+		return nil, fmt.Errorf("logger: unknown encoding %q", encoding)
+	}
+
+	if config.LevelEncoding != "" {
+		levelEncoder, ok := levelEncoders[config.LevelEncoding]
+		if !ok {
+			return nil, fmt.Errorf("logger: unknown level encoding %q", config.LevelEncoding)
+		}
+		encoderConfig.EncodeLevel = levelEncoder
+	}
+
+	switch encoding {
+	case consoleEncoding:
+		return zapcore.NewConsoleEncoder(encoderConfig), nil
+	case logfmtEncoding:
+		return newLogfmtEncoder(encoderConfig), nil
+	}
+	return zapcore.NewJSONEncoder(encoderConfig), nil
+}
+
+var levelEncoders = map[string]zapcore.LevelEncoder{
+	"capital":      zapcore.CapitalLevelEncoder,
+	"lower":        zapcore.LowercaseLevelEncoder,
+	"capitalColor": zapcore.CapitalColorLevelEncoder,
+	"lowerColor":   zapcore.LowercaseColorLevelEncoder,
+}
+
+// iso8601Layout matches the layout of zapcore.ISO8601TimeEncoder.
+const iso8601Layout = "2006-01-02T15:04:05.000Z0700"
+
+func timeEncoder(layout string, location *time.Location) zapcore.TimeEncoder {
+	if layout == "" {
+		layout = iso8601Layout
+	}
+
+	return func(t time.Time, enc zapcore.PrimitiveArrayEncoder) {
+		if location != nil {
+			t = t.In(location)
+		}
+		enc.AppendString(t.Format(layout))
+	}
+}
+
 func (l *Logger) Debug(ctx context.Context, args ...any) {
-	msg := fmt.Sprint(args...)
-	combinedAttributes := l.combineAttributes(ctx)
-	l.logger.Debugw(msg, combinedAttributes...)
+	l.log(ctx, zapcore.DebugLevel, sprint(args...), nil)
 }
 
 func (l *Logger) Info(ctx context.Context, args ...any) {
-	msg := fmt.Sprint(args...)
-	combinedAttributes := l.combineAttributes(ctx)
-	l.logger.Infow(msg, combinedAttributes...)
+	l.log(ctx, zapcore.InfoLevel, sprint(args...), nil)
 }
 
 func (l *Logger) Warn(ctx context.Context, args ...any) {
-	msg := fmt.Sprint(args...)
-	combinedAttributes := l.combineAttributes(ctx)
-	l.logger.Warnw(msg, combinedAttributes...)
+	l.log(ctx, zapcore.WarnLevel, sprint(args...), nil)
 }
 
 func (l *Logger) Error(ctx context.Context, args ...any) {
-	msg := fmt.Sprint(args...)
-	combinedAttributes := l.combineAttributes(ctx)
-	l.logger.Errorw(msg, combinedAttributes...)
+	l.log(ctx, zapcore.ErrorLevel, sprint(args...), nil)
 }
 
 func (l *Logger) Panic(ctx context.Context, args ...any) {
-	msg := fmt.Sprint(args...)
-	combinedAttributes := l.combineAttributes(ctx)
-	l.logger.Panicw(msg, combinedAttributes...)
+	l.log(ctx, zapcore.PanicLevel, sprint(args...), nil)
 }
 
 func (l *Logger) Fatal(ctx context.Context, args ...any) {
-	msg := fmt.Sprint(args...)
-	combinedAttributes := l.combineAttributes(ctx)
-	l.logger.Fatalw(msg, combinedAttributes...)
+	l.log(ctx, zapcore.FatalLevel, sprint(args...), nil)
 }
 
 func (l *Logger) Debugf(ctx context.Context, template string, args ...any) {
-	msg := fmt.Sprintf(template, args...)
-	combinedAttributes := l.combineAttributes(ctx)
-	l.logger.Debugw(msg, combinedAttributes...)
+	l.log(ctx, zapcore.DebugLevel, fmt.Sprintf(template, args...), nil)
 }
 
 func (l *Logger) Infof(ctx context.Context, template string, args ...any) {
-	msg := fmt.Sprintf(template, args...)
-	combinedAttributes := l.combineAttributes(ctx)
-	l.logger.Infow(msg, combinedAttributes...)
+	l.log(ctx, zapcore.InfoLevel, fmt.Sprintf(template, args...), nil)
 }
 
 func (l *Logger) Warnf(ctx context.Context, template string, args ...any) {
-	msg := fmt.Sprintf(template, args...)
-	combinedAttributes := l.combineAttributes(ctx)
-	l.logger.Warnw(msg, combinedAttributes...)
+	l.log(ctx, zapcore.WarnLevel, fmt.Sprintf(template, args...), nil)
 }
 
 func (l *Logger) Errorf(ctx context.Context, template string, args ...any) {
-	msg := fmt.Sprintf(template, args...)
-	combinedAttributes := l.combineAttributes(ctx)
-	l.logger.Errorw(msg, combinedAttributes...)
+	l.log(ctx, zapcore.ErrorLevel, fmt.Sprintf(template, args...), nil)
 }
 
 func (l *Logger) Panicf(ctx context.Context, template string, args ...any) {
-	msg := fmt.Sprintf(template, args...)
-	combinedAttributes := l.combineAttributes(ctx)
-	l.logger.Panicw(msg, combinedAttributes...)
+	l.log(ctx, zapcore.PanicLevel, fmt.Sprintf(template, args...), nil)
 }
 
 func (l *Logger) Fatalf(ctx context.Context, template string, args ...any) {
-	msg := fmt.Sprintf(template, args...)
-	combinedAttributes := l.combineAttributes(ctx)
-	l.logger.Fatalw(msg, combinedAttributes...)
+	l.log(ctx, zapcore.FatalLevel, fmt.Sprintf(template, args...), nil)
+}
+
+func (l *Logger) Debugln(ctx context.Context, args ...any) {
+	l.log(ctx, zapcore.DebugLevel, sprintln(args...), nil)
+}
+
+func (l *Logger) Infoln(ctx context.Context, args ...any) {
+	l.log(ctx, zapcore.InfoLevel, sprintln(args...), nil)
+}
+
+func (l *Logger) Warnln(ctx context.Context, args ...any) {
+	l.log(ctx, zapcore.WarnLevel, sprintln(args...), nil)
+}
+
+func (l *Logger) Errorln(ctx context.Context, args ...any) {
+	l.log(ctx, zapcore.ErrorLevel, sprintln(args...), nil)
+}
+
+func (l *Logger) Panicln(ctx context.Context, args ...any) {
+	l.log(ctx, zapcore.PanicLevel, sprintln(args...), nil)
+}
+
+func (l *Logger) Fatalln(ctx context.Context, args ...any) {
+	l.log(ctx, zapcore.FatalLevel, sprintln(args...), nil)
 }
 
 func (l *Logger) Debugw(ctx context.Context, msg string, keysAndValues ...any) {
-	combinedAttributes := l.combineAttributes(ctx, keysAndValues...)
-	l.logger.Debugw(msg, combinedAttributes...)
+	l.log(ctx, zapcore.DebugLevel, msg, keysAndValues)
 }
 
 func (l *Logger) Infow(ctx context.Context, msg string, keysAndValues ...any) {
-	combinedAttributes := l.combineAttributes(ctx, keysAndValues...)
-	l.logger.Infow(msg, combinedAttributes...)
+	l.log(ctx, zapcore.InfoLevel, msg, keysAndValues)
 }
 
 func (l *Logger) Warnw(ctx context.Context, msg string, keysAndValues ...any) {
-	combinedAttributes := l.combineAttributes(ctx, keysAndValues...)
-	l.logger.Warnw(msg, combinedAttributes...)
+	l.log(ctx, zapcore.WarnLevel, msg, keysAndValues)
 }
 
 func (l *Logger) Errorw(ctx context.Context, msg string, keysAndValues ...any) {
-	combinedAttributes := l.combineAttributes(ctx, keysAndValues...)
-	l.logger.Errorw(msg, combinedAttributes...)
+	l.log(ctx, zapcore.ErrorLevel, msg, keysAndValues)
 }
 
 func (l *Logger) Panicw(ctx context.Context, msg string, keysAndValues ...any) {
-	combinedAttributes := l.combineAttributes(ctx, keysAndValues...)
-	l.logger.Panicw(msg, combinedAttributes...)
+	l.log(ctx, zapcore.PanicLevel, msg, keysAndValues)
 }
 
 func (l *Logger) Fatalw(ctx context.Context, msg string, keysAndValues ...any) {
-	combinedAttributes := l.combineAttributes(ctx, keysAndValues...)
-	l.logger.Fatalw(msg, combinedAttributes...)
+	l.log(ctx, zapcore.FatalLevel, msg, keysAndValues)
+}
+
+// DebugwIf, InfowIf, WarnwIf and ErrorwIf log only when cond is true, doing
+// no other work otherwise, e.g. to log only the first iteration of a loop.
+func (l *Logger) DebugwIf(ctx context.Context, cond bool, msg string, keysAndValues ...any) {
+	if cond {
+		l.log(ctx, zapcore.DebugLevel, msg, keysAndValues)
+	}
+}
+
+func (l *Logger) InfowIf(ctx context.Context, cond bool, msg string, keysAndValues ...any) {
+	if cond {
+		l.log(ctx, zapcore.InfoLevel, msg, keysAndValues)
+	}
+}
+
+func (l *Logger) WarnwIf(ctx context.Context, cond bool, msg string, keysAndValues ...any) {
+	if cond {
+		l.log(ctx, zapcore.WarnLevel, msg, keysAndValues)
+	}
+}
+
+func (l *Logger) ErrorwIf(ctx context.Context, cond bool, msg string, keysAndValues ...any) {
+	if cond {
+		l.log(ctx, zapcore.ErrorLevel, msg, keysAndValues)
+	}
+}
+
+// sprint formats args like fmt.Sprint, without allocating for the common
+// case of a single string.
+func sprint(args ...any) string {
+	switch len(args) {
+	case 0:
+		return ""
+	case 1:
+		if s, ok := args[0].(string); ok {
+			return s
+		}
+	}
+	return fmt.Sprint(args...)
+}
+
+// sprintln formats args like fmt.Sprintln, always adding spaces between
+// operands, without the trailing newline.
+func sprintln(args ...any) string {
+	msg := fmt.Sprintln(args...)
+	return msg[:len(msg)-1]
+}
+
+// WithFields returns a child logger that adds the given key-value pairs to
+// every log line. The child shares the underlying zap logger with its parent.
+func (l *Logger) WithFields(keysAndValues ...any) *Logger {
+	child := *l
+	child.fields = make([]any, 0, len(l.fields)+len(l.groups)+len(keysAndValues))
+	child.fields = append(child.fields, l.fields...)
+	if len(keysAndValues) > 0 {
+		child.fields = l.openGroups(child.fields)
+		child.groups = nil
+	}
+	child.fields = append(child.fields, keysAndValues...)
+	return &child
+}
+
+// WithGroup returns a child logger that nests the fields bound after it with
+// WithFields, and those passed to each call, under name, e.g.
+// "http":{"method":"GET"}. Context fields such as request_id, fixed fields
+// and fields bound before the group stay at the top level. A group without
+// any fields under it is left out of the entry.
+func (l *Logger) WithGroup(name string) *Logger {
+	child := *l
+	child.groups = make([]string, 0, len(l.groups)+1)
+	child.groups = append(child.groups, l.groups...)
+	child.groups = append(child.groups, name)
+	return &child
+}
+
+// openGroups appends a zap.Namespace for each group opened with WithGroup
+// that has no fields under it yet. Callers only open the groups when a field
+// follows, so an empty group is never written.
+func (l *Logger) openGroups(attributes []any) []any {
+	for _, group := range l.groups {
+		attributes = append(attributes, zap.Namespace(group))
+	}
+	return attributes
+}
+
+// SetFixedKeyValue adds or replaces a field included in every log line. It
+// also applies to children created with WithFields, Named and similar
+// methods, but not to clones.
+func (l *Logger) SetFixedKeyValue(key string, value any) {
+	l.fixedKeyValues.set(key, value)
+}
+
+func (l *Logger) RemoveFixedKeyValue(key string) {
+	l.fixedKeyValues.remove(key)
+}
+
+// Clone returns an independent copy of the logger that shares the underlying
+// zap core. The clone has its own fixed key-values, extra fields and bound
+// fields, so later changes to them do not affect the original.
+func (l *Logger) Clone() *Logger {
+	clone := *l
+	clone.fixedKeyValues = l.fixedKeyValues.clone()
+	clone.extraFields = slices.Clone(l.extraFields)
+	clone.fields = slices.Clone(l.fields)
+	clone.extractors = slices.Clone(l.extractors)
+	clone.multiExtractors = slices.Clone(l.multiExtractors)
+	return &clone
+}
+
+// setBase sets the zap loggers for regular entries and for entries logged
+// with a context passed through EnableTrace.
+func (l *Logger) setBase(base, traceBase *zap.Logger) {
+	l.base, l.logger = base, base.Sugar()
+	l.traceBase, l.traceLogger = traceBase, traceBase.Sugar()
+}
+
+// Named returns a child logger with name appended to the logger name, so
+// chained calls produce dotted names such as "api.billing.stripe".
+func (l *Logger) Named(name string) *Logger {
+	child := *l
+	child.setBase(l.base.Named(name), l.traceBase.Named(name))
+	return &child
+}
+
+// WithZapFields returns a child logger with strongly-typed fields bound to
+// it. The fields are encoded once, ahead of all other fields, which avoids
+// the reflection and allocations of sugared key-value pairs. RedactKeys and
+// MaxFieldBytes are applied when they are bound.
+func (l *Logger) WithZapFields(fields ...zap.Field) *Logger {
+	fields = l.sanitizeZapFields(fields)
+	child := *l
+	child.setBase(l.base.With(fields...), l.traceBase.With(fields...))
+	return &child
+}
+
+// sanitizeZapFields applies redaction and truncation to fields that are
+// encoded ahead of time and so never pass through combineAttributes.
+func (l *Logger) sanitizeZapFields(fields []zap.Field) []zap.Field {
+	if len(l.redactKeys) == 0 && l.maxFieldBytes <= 0 {
+		return fields
+	}
+
+	keysAndValues := make([]any, len(fields))
+	for i, field := range fields {
+		keysAndValues[i] = field
+	}
+	l.redactAttributes(keysAndValues)
+	l.truncateAttributes(keysAndValues)
+	return attributesToFields(keysAndValues)
+}
+
+// WithError returns a child logger that adds err under the "error" key. Errors
+// that carry a stack trace, such as those from github.com/pkg/errors, also
+// get an "errorVerbose" field. A nil error returns l unchanged.
+func (l *Logger) WithError(err error) *Logger {
+	if err == nil {
+		return l
+	}
+	return l.WithZapFields(zap.Error(err))
+}
+
+// Logw logs a message with key-value pairs at the given level. Unknown levels
+// are logged at Info.
+func (l *Logger) Logw(ctx context.Context, level Level, msg string, keysAndValues ...any) {
+	zl, err := level.zapLevel()
+	if err != nil {
+		zl = zapcore.InfoLevel
+	}
+	l.log(ctx, zl, msg, keysAndValues)
+}
+
+// LogMap is Logw with the fields given as a map. They are logged sorted by
+// key.
+func (l *Logger) LogMap(ctx context.Context, level Level, msg string, fields map[string]any) {
+	zl, err := level.zapLevel()
+	if err != nil {
+		zl = zapcore.InfoLevel
+	}
+	l.log(ctx, zl, msg, mapToAttributes(fields))
+}
+
+func mapToAttributes(fields map[string]any) []any {
+	keysAndValues := make([]any, 0, 2*len(fields))
+	for _, k := range slices.Sorted(maps.Keys(fields)) {
+		keysAndValues = append(keysAndValues, k, fields[k])
+	}
+	return keysAndValues
+}
+
+// Flush writes any buffered log entries to their sink. Syncing a terminal or
+// pipe on stderr commonly fails with "sync /dev/stderr: invalid argument";
+// that error is harmless, see FlushIgnoringStderr.
+func (l *Logger) Flush() error {
+	return l.logger.Sync()
+}
+
+// FlushIgnoringStderr is like Flush but drops the errors returned when
+// stdout or stderr cannot be synced.
+func (l *Logger) FlushIgnoringStderr() error {
+	var remaining error
+	for _, err := range multierr.Errors(l.Flush()) {
+		if !isStdSyncError(err) {
+			remaining = multierr.Append(remaining, err)
+		}
+	}
+	return remaining
+}
+
+// Close flushes the logger and closes the files it opened for RotationConfig
+// and OutputPaths. Writers passed in Output or Sinks are left open for their
+// owner to close. The logger, and every logger derived from it, must not be
+// used afterwards.
+func (l *Logger) Close() error {
+	return multierr.Append(l.FlushIgnoringStderr(), l.close())
+}
+
+// Rotate flushes the logger and starts a new RotationConfig file, renaming
+// the current one like a size-based rotation would, e.g. after the file has
+// been archived. It only flushes when RotationConfig is not set.
+func (l *Logger) Rotate() error {
+	err := l.FlushIgnoringStderr()
+	if l.rotate == nil {
+		return err
+	}
+	return multierr.Append(err, l.rotate())
+}
+
+func isStdSyncError(err error) bool {
+	var pathErr *fs.PathError
+	if !errors.As(err, &pathErr) {
+		return false
+	}
+	if pathErr.Path != os.Stderr.Name() && pathErr.Path != os.Stdout.Name() {
+		return false
+	}
+	return errors.Is(pathErr.Err, syscall.EINVAL) || errors.Is(pathErr.Err, syscall.ENOTTY)
+}
+
+// SetLevel changes the minimum enabled level at runtime. It is safe to call
+// concurrently with logging. Unknown levels are ignored.
+func (l *Logger) SetLevel(level Level) {
+	zl, err := level.zapLevel()
+	if err != nil {
+		return
+	}
+	l.level.SetLevel(zl)
+}
+
+func (l *Logger) GetLevel() Level {
+	return levelFromZap(l.level.Level())
+}
+
+// WithTemporaryLevel sets the level and returns a function that restores the
+// previous one, e.g. defer l.WithTemporaryLevel(DebugLevel)() around a block
+// being debugged. The level is shared by the logger, its children and every
+// goroutine using them, so this is best kept to single-threaded paths such
+// as startup. An unknown level leaves the level unchanged.
+func (l *Logger) WithTemporaryLevel(level Level) (restore func()) {
+	previous := l.level.Level()
+	l.SetLevel(level)
+	return func() {
+		l.level.SetLevel(previous)
+	}
+}
+
+// LevelEnabled reports whether entries at level would be logged, so callers
+// can skip building expensive payloads that would be dropped.
+func (l *Logger) LevelEnabled(level Level) bool {
+	zl, err := level.zapLevel()
+	if err != nil {
+		return false
+	}
+	return l.base.Core().Enabled(zl)
 }
 
-func (l *Logger) Flush() {
-	l.logger.Sync()
+func (l *Logger) DebugEnabled() bool {
+	return l.LevelEnabled(DebugLevel)
 }
 
 func (l *Logger) IsDevMode() bool {
 	return l.devMode
 }
 
+// GenerateRequestID returns RequestIDPrefix followed by a new ID from
+// RequestIDGenerator, or a random UUID when no generator is configured.
 func (l *Logger) GenerateRequestID() string {
+	if l.requestIDGenerator != nil {
+		return l.requestIDPrefix + l.requestIDGenerator()
+	}
 	return l.requestIDPrefix + uuid.New().String()
 }
 
+// NewSubRequest returns a context whose request ID is the request ID of ctx
+// followed by "." and suffix, e.g. "req-1.billing", so the logs of a
+// sub-operation can be told apart yet still found with the parent ID. A
+// request ID is generated first when ctx has none.
+func (l *Logger) NewSubRequest(ctx context.Context, suffix string) context.Context {
+	parent, ok := l.GetRequestID(ctx)
+	if !ok || parent == "" {
+		parent = l.GenerateRequestID()
+	}
+	return l.SetRequestID(ctx, parent+"."+suffix)
+}
+
+// ValidRequestID reports whether an inbound request ID may be reused, using
+// RequestIDValidator or, when it is not set, the default rules.
+func (l *Logger) ValidRequestID(id string) bool {
+	if l.requestIDValidator != nil {
+		return l.requestIDValidator(id)
+	}
+	return defaultRequestIDPattern.MatchString(id)
+}
+
+// ContextWithLogger returns a copy of ctx carrying l. The middleware stores
+// itself this way so handlers can enrich the logger it used.
+func ContextWithLogger(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, loggerKey, l)
+}
+
+// LoggerFromContext returns the logger stored in ctx by ContextWithLogger.
+func LoggerFromContext(ctx context.Context) (*Logger, bool) {
+	l, ok := ctx.Value(loggerKey).(*Logger)
+	return l, ok && l != nil
+}
+
+// FromContext returns the logger stored in ctx, or the global logger if there
+// is none.
+func FromContext(ctx context.Context) *Logger {
+	if l, ok := LoggerFromContext(ctx); ok {
+		return l
+	}
+	return global()
+}
+
+// RequestIDFromContext returns the request ID stored in ctx by SetRequestID or
+// the middleware, without needing a Logger.
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	requestId, ok := ctx.Value(requestIdKey).(string)
+	return requestId, ok
+}
+
+// UserFromContext returns the user stored in ctx by SetUser, without needing a
+// Logger.
+func UserFromContext(ctx context.Context) (any, bool) {
+	user := ctx.Value(userKey)
+	return user, user != nil
+}
+
 func (l *Logger) SetRequestID(ctx context.Context, requestId string) context.Context {
 	return context.WithValue(ctx, requestIdKey, requestId)
 }
 
 func (l *Logger) GetRequestID(ctx context.Context) (string, bool) {
-	requestId, ok := ctx.Value(requestIdKey).(string)
-	return requestId, ok
+	return RequestIDFromContext(ctx)
 }
 
 func (l *Logger) SetUser(ctx context.Context, user any) context.Context {
@@ -198,10 +986,77 @@ func (l *Logger) SetUser(ctx context.Context, user any) context.Context {
 }
 
 func (l *Logger) GetUser(ctx context.Context) (any, bool) {
-	user := ctx.Value(userKey)
-	return user, user != nil
+	return UserFromContext(ctx)
+}
+
+// SetTenant tags the logs written with the returned context with a
+// tenant_id, for multitenant apps.
+func (l *Logger) SetTenant(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, tenantKey, id)
+}
+
+func (l *Logger) GetTenant(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(tenantKey).(string)
+	return id, ok
+}
+
+func (l *Logger) SetUserIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, userIPKey, ip)
+}
+
+func (l *Logger) GetUserIP(ctx context.Context) (string, bool) {
+	ip, ok := ctx.Value(userIPKey).(string)
+	return ip, ok
+}
+
+// EnableTrace returns a context whose entries are logged at every level,
+// including Debug, whatever the logger level, e.g. to follow one request end
+// to end.
+func (l *Logger) EnableTrace(ctx context.Context) context.Context {
+	return context.WithValue(ctx, traceKey, true)
+}
+
+// IsTraced reports whether ctx was passed through EnableTrace.
+func IsTraced(ctx context.Context) bool {
+	traced, _ := ctx.Value(traceKey).(bool)
+	return traced
+}
+
+// WithWorkerID tags the logs written with the returned context with a
+// worker_id, e.g. to tell apart goroutines working on the same request.
+func (l *Logger) WithWorkerID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, workerIDKey, id)
+}
+
+func (l *Logger) GetWorkerID(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(workerIDKey).(string)
+	return id, ok
+}
+
+// AppendField returns a context whose entries carry key and value, in
+// addition to the fields appended to ctx before, without a child logger. The
+// fields are logged after the extractor fields and survive DetachContext.
+func (l *Logger) AppendField(ctx context.Context, key string, value any) context.Context {
+	existing := appendedFields(ctx)
+	fields := make([]any, 0, len(existing)+2)
+	fields = append(fields, existing...)
+	fields = append(fields, key, value)
+	return context.WithValue(ctx, fieldsKey, fields)
+}
+
+func appendedFields(ctx context.Context) []any {
+	fields, _ := ctx.Value(fieldsKey).([]any)
+	return fields
+}
+
+// SetExtraField stores the value of a configured extra field in ctx.
+func (l *Logger) SetExtraField(ctx context.Context, field string, value any) context.Context {
+	return context.WithValue(ctx, extraFieldKey(field), value)
 }
 
+// GetExtraFields returns the configured extra fields present in ctx. Values
+// stored with SetExtraField take precedence over values stored directly
+// under the plain string key.
 func (l *Logger) GetExtraFields(ctx context.Context) (map[string]any, bool) {
 	if len(l.extraFields) == 0 {
 		return nil, false
@@ -209,7 +1064,9 @@ func (l *Logger) GetExtraFields(ctx context.Context) (map[string]any, bool) {
 
 	pairs := make(map[string]any)
 	for _, field := range l.extraFields {
-		if value := ctx.Value(field); value != nil {
+		if value := ctx.Value(extraFieldKey(field)); value != nil {
+			pairs[field] = value
+		} else if value := ctx.Value(field); value != nil {
 			pairs[field] = value
 		}
 	}
@@ -217,88 +1074,659 @@ func (l *Logger) GetExtraFields(ctx context.Context) (map[string]any, bool) {
 	return pairs, true
 }
 
+// DetachContext returns a new background context carrying the logging values
+// (request ID, user, tenant, user IP, worker ID, trace flag, extra and
+// appended fields) of ctx but none of its cancellation or deadline, for work
+// that outlives the original request.
+func (l *Logger) DetachContext(ctx context.Context) context.Context {
+	newCtx := context.Background()
+
+	if requestId, ok := l.GetRequestID(ctx); ok {
+		newCtx = l.SetRequestID(newCtx, requestId)
+	}
+	if user, ok := l.GetUser(ctx); ok {
+		newCtx = l.SetUser(newCtx, user)
+	}
+	if id, ok := l.GetTenant(ctx); ok {
+		newCtx = l.SetTenant(newCtx, id)
+	}
+	if ip, ok := l.GetUserIP(ctx); ok {
+		newCtx = l.SetUserIP(newCtx, ip)
+	}
+	if id, ok := l.GetWorkerID(ctx); ok {
+		newCtx = l.WithWorkerID(newCtx, id)
+	}
+	if IsTraced(ctx) {
+		newCtx = l.EnableTrace(newCtx)
+	}
+	if fields := appendedFields(ctx); fields != nil {
+		newCtx = context.WithValue(newCtx, fieldsKey, fields)
+	}
+	if extraFields, ok := l.GetExtraFields(ctx); ok {
+		for k, v := range extraFields {
+			newCtx = l.SetExtraField(newCtx, k, v)
+		}
+	}
+
+	return newCtx
+}
+
+// DetachContextWith is DetachContext that also copies the values stored in
+// ctx under each of keys, e.g. a tenant ID set with a typed key.
+func (l *Logger) DetachContextWith(ctx context.Context, keys ...any) context.Context {
+	newCtx := l.DetachContext(ctx)
+	for _, key := range keys {
+		if value := ctx.Value(key); value != nil {
+			newCtx = context.WithValue(newCtx, key, value)
+		}
+	}
+	return newCtx
+}
+
+// WithTimeout detaches ctx and applies a fresh timeout to the result.
+func (l *Logger) WithTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(l.DetachContext(ctx), timeout)
+}
+
+// DetachGroupContext detaches ctx and makes the result cancelable, e.g. for
+// errgroup.WithContext in fan-out work that must not be canceled with the
+// request. Unlike WithTimeout there is no deadline; the work runs until it
+// finishes or cancel is called, which the caller must do to release it.
+func (l *Logger) DetachGroupContext(ctx context.Context) (context.Context, func()) {
+	return context.WithCancel(l.DetachContext(ctx))
+}
+
+// Go runs fn in a new goroutine with a context detached from ctx. A panic in
+// fn is recovered and logged at Error level with its stack and the request
+// ID and other logging values of ctx, instead of crashing the process.
+func (l *Logger) Go(ctx context.Context, fn func(ctx context.Context)) {
+	detached := l.DetachContext(ctx)
+	go func() {
+		defer func() {
+			if rec := recover(); rec != nil {
+				l.Errorw(detached, "Recovered from panic in goroutine",
+					"panic", rec,
+					"stack", string(debug.Stack()),
+				)
+			}
+		}()
+		fn(detached)
+	}()
+}
+
+// extractFields runs the configured extractors, keeping the position of the
+// first occurrence of each key and the value of the last.
+func (l *Logger) extractFields(ctx context.Context) []any {
+	if len(l.extractors) == 0 && len(l.multiExtractors) == 0 {
+		return nil
+	}
+
+	var pairs []any
+	positions := make(map[string]int)
+	add := func(key string, value any) {
+		if i, seen := positions[key]; seen {
+			pairs[i+1] = value
+			return
+		}
+		positions[key] = len(pairs)
+		pairs = append(pairs, key, value)
+	}
+	for _, extract := range l.extractors {
+		if key, value, ok := extract(ctx); ok {
+			add(key, value)
+		}
+	}
+	for _, extract := range l.multiExtractors {
+		// A non-string key and a trailing key without a value are dropped
+		keysAndValues := extract(ctx)
+		for i := 0; i+1 < len(keysAndValues); i += 2 {
+			if key, ok := keysAndValues[i].(string); ok {
+				add(key, keysAndValues[i+1])
+			}
+		}
+	}
+
+	return pairs
+}
+
+// combineAttributes builds the key-value pairs of a log line in a stable
+// order: fixed key-values sorted by key, request ID, user, tenant, user IP,
+// worker ID, trace context, deadline, extra fields sorted by key, extractor
+// fields, fields appended to the context, bound fields, and finally the
+// caller's keysAndValues in the order given.
 func (l *Logger) combineAttributes(ctx context.Context, keysAndValues ...any) []any {
 	var combined []any
 
-	for k, v := range l.fixedKeyValues {
-		combined = append(combined, k, v)
-	}
+	combined = l.fixedKeyValues.appendTo(combined)
+	contextStart := len(combined)
 	if requestId, ok := l.GetRequestID(ctx); ok {
 		combined = append(combined, requestIdContextKey, requestId)
 	}
 	if user, ok := l.GetUser(ctx); ok {
+		if l.userFormatter != nil {
+			user = l.userFormatter(user)
+		}
 		combined = append(combined, userContextKey, user)
 	}
+	if id, ok := l.GetTenant(ctx); ok {
+		combined = append(combined, tenantContextKey, id)
+	}
+	if ip, ok := l.GetUserIP(ctx); ok {
+		combined = append(combined, userIPContextKey, ip)
+	}
+	if id, ok := l.GetWorkerID(ctx); ok {
+		combined = append(combined, workerIDContextKey, id)
+	}
+	if l.traceContext {
+		if spanCtx := trace.SpanContextFromContext(ctx); spanCtx.IsValid() {
+			combined = append(combined,
+				traceIDContextKey, spanCtx.TraceID().String(),
+				spanIDContextKey, spanCtx.SpanID().String(),
+			)
+		}
+	}
+	if l.includeDeadline {
+		if deadline, ok := ctx.Deadline(); ok {
+			combined = append(combined, deadlineContextKey, float64(time.Until(deadline))/float64(time.Millisecond))
+		}
+	}
 	if extraFields, ok := l.GetExtraFields(ctx); ok {
-		for k, v := range extraFields {
-			combined = append(combined, k, v)
+		builtIn := combined[contextStart:]
+		for _, k := range slices.Sorted(maps.Keys(extraFields)) {
+			// An extra field named like a context value already logged,
+			// e.g. "user", would duplicate the key
+			if !hasKey(builtIn, k) {
+				combined = append(combined, k, extraFields[k])
+			}
 		}
 	}
+	combined = append(combined, l.extractFields(ctx)...)
+	combined = append(combined, appendedFields(ctx)...)
 
+	combined = append(combined, l.fields...)
+	if len(keysAndValues) > 0 {
+		combined = l.openGroups(combined)
+	}
 	combined = append(combined, keysAndValues...)
+	errorsToFields(combined)
+	l.redactAttributes(combined)
+	l.truncateAttributes(combined)
 	return combined
 }
 
+// hasKey reports whether a key-value slice without zap.Field entries contains
+// key.
+func hasKey(pairs []any, key string) bool {
+	for i := 0; i < len(pairs); i += 2 {
+		if pairs[i] == key {
+			return true
+		}
+	}
+	return false
+}
+
+// log writes an entry at level. Every leveled method, including the global
+// ones, calls it directly so the caller skip is the same for all of them;
+// code inside this package logs through the public methods instead.
+func (l *Logger) log(ctx context.Context, level zapcore.Level, msg string, keysAndValues []any) {
+	logger := l.logger
+	if IsTraced(ctx) {
+		logger = l.traceLogger
+	}
+
+	if l.sanitizeMessages {
+		msg = sanitizeMessage(msg)
+	}
+	if l.strictFields {
+		var problems []string
+		if keysAndValues, problems = validateKeysAndValues(keysAndValues); len(problems) > 0 {
+			logger.Warnw("Ignored invalid log fields", l.combineAttributes(ctx, "log_message", msg, "problems", problems)...)
+		}
+	}
+
+	combinedAttributes := l.combineAttributes(ctx, keysAndValues...)
+	if l.onError == nil || level < zapcore.ErrorLevel {
+		logger.Logw(level, msg, combinedAttributes...)
+		return
+	}
+
+	// Checked first so OnError only runs for entries that are written, not
+	// for those dropped by sampling or deduplication
+	base := l.base
+	if IsTraced(ctx) {
+		base = l.traceBase
+	}
+	entry := base.Check(level, msg)
+	if entry == nil {
+		return
+	}
+
+	notify := func() { l.notifyError(ctx, msg, combinedAttributes) }
+	if level >= zapcore.FatalLevel {
+		// Fatal exits right after writing, so notify from the exit hook
+		entry = entry.After(entry.Entry, exitAfter(notify))
+	} else {
+		// Deferred so the hook also runs when a Panic entry panics after writing
+		defer notify()
+	}
+	entry.Write(attributesToFields(combinedAttributes)...)
+}
+
+// MiddlewareConfig configures the HTTP middleware returned by
+// LoggerMiddlewareWithConfig.
+type MiddlewareConfig struct {
+	LogRequestDetails bool
+	LogCompleteTime   bool
+	BypassList        []BypassRequestLogging
+
+	// UniformCompletionLevel logs every completed request at Info instead of
+	// Warn for 4xx and Error for 5xx responses.
+	UniformCompletionLevel bool
+
+	// RequestIDHeaders are checked in order for an inbound request ID to
+	// reuse instead of generating one, e.g. X-Request-ID and X-Correlation-ID.
+	// The final request ID is echoed on the response under the first header.
+	// Defaults to X-Request-ID.
+	RequestIDHeaders []string
+
+	// RecoverPanics recovers panics from downstream handlers, logs them with
+	// their stack at Error level and responds with 500. Leave it disabled if
+	// another middleware already handles recovery.
+	RecoverPanics bool
+
+	// PanicResponse, when set, writes the response for a recovered panic
+	// instead of a plain-text "Internal Server Error", e.g. JSONPanicResponse
+	// so clients get the request ID to quote in support tickets. It is only
+	// called if the handler wrote nothing before panicking.
+	PanicResponse func(w http.ResponseWriter, requestID string)
+
+	// LogRequestBody adds up to MaxBodyBytes (default 4096) of the request
+	// body to the "Incoming request" log, marking longer bodies with
+	// "...[truncated]". Only bodies whose Content-Type is listed in
+	// BodyContentTypes (default application/json) are logged. Requires
+	// LogRequestDetails.
+	LogRequestBody   bool
+	MaxBodyBytes     int
+	BodyContentTypes []string
+
+	// SlowRequestThreshold, when set, logs the completion of requests that
+	// take longer at Warn or above with slow=true.
+	SlowRequestThreshold time.Duration
+
+	// Filter, when set, skips request and completion logging for requests
+	// it returns true for, e.g. Kubernetes probes. It is checked in addition
+	// to BypassList.
+	Filter func(r *http.Request) bool
+
+	// RequestDetailGroups selects which categories of request details are
+	// logged, e.g. BasicInfo | ClientInfo. Zero means AllRequestDetails.
+	// The groups choose among the default headers; a HeaderAllowList is
+	// logged as given.
+	RequestDetailGroups RequestDetailGroup
+
+	// HeaderAllowList names the request headers added to the request
+	// details, under snake_case keys such as user_agent. It defaults to
+	// common client, content and proxy headers. ResponseHeaderAllowList
+	// names response headers added to the completion log under
+	// "response_headers". Multiple values are joined with ", ".
+	HeaderAllowList         []string
+	ResponseHeaderAllowList []string
+
+	// RedactQueryParams lists query parameter names, matched
+	// case-insensitively, whose values are replaced with "REDACTED" in the
+	// logged url and query_params, e.g. "token". A redacted query is
+	// re-encoded, so its parameters are logged sorted by name.
+	RedactQueryParams []string
+
+	// LogTLS adds tls_version, tls_cipher and tls_sni to the request
+	// details of HTTPS requests. Requires LogRequestDetails.
+	LogTLS bool
+
+	// OmitEmptyDetails leaves request details with an empty or zero value,
+	// such as absent headers, out of the log. By default every detail is
+	// logged so the schema stays the same for all requests.
+	OmitEmptyDetails bool
+
+	// TagClientDisconnects marks completions of requests whose context was
+	// canceled, typically because the client went away, with
+	// client_disconnected=true and logs them at Info whatever the status.
+	TagClientDisconnects bool
+
+	// LogMode selects between separate incoming and completion lines
+	// (TwoLine, the default) and one line per request (SingleLine).
+	LogMode LogMode
+	// RequestScope stores a RequestScope in the request context, see
+	// RequestScopeFromContext. Its fields and events are added to the
+	// "Request completed" line, or to the "access" entry with AccessLog,
+	// rather than logged separately, so it requires LogCompleteTime,
+	// SingleLine or AccessLog.
+	RequestScope bool
+
+	// AccessLog writes each completed request as an AccessLog "access" entry
+	// with a fixed schema instead of "Request completed", whatever
+	// LogCompleteTime and LogMode say. RequestScope fields and events follow
+	// the schema fields. Requests skipped by BypassList or Filter are still
+	// left out.
+	AccessLog bool
+}
+
+// LogMode controls how many lines the middleware writes per request.
+type LogMode int
+
+const (
+	// TwoLine writes "Incoming request" when LogRequestDetails is set and
+	// "Request completed" when LogCompleteTime is set.
+	TwoLine LogMode = iota
+
+	// SingleLine writes only "Request completed", with the method and path
+	// added, and the request details when LogRequestDetails is set.
+	SingleLine
+)
+
+const defaultRequestIDHeader = "X-Request-ID"
+
+// osHostname is os.Hostname, replaced in tests to simulate a lookup failure.
+var osHostname = os.Hostname
+
+// defaultRequestIDPattern accepts inbound request IDs when
+// LoggerConfig.RequestIDValidator is not set.
+var defaultRequestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:+/=-]{1,128}$`)
+
 func (l *Logger) LoggerMiddleware(logRequestDetails bool, logCompleteTime bool, bypassList ...BypassRequestLogging) func(next http.Handler) http.Handler {
-	compiledBypassList := compileBypassPatterns(bypassList)
+	return l.LoggerMiddlewareWithConfig(MiddlewareConfig{
+		LogRequestDetails: logRequestDetails,
+		LogCompleteTime:   logCompleteTime,
+		BypassList:        bypassList,
+	})
+}
+
+// LoggerMiddlewareWithFilter is LoggerMiddleware that also skips logging for
+// requests filter returns true for.
+func (l *Logger) LoggerMiddlewareWithFilter(filter func(r *http.Request) bool, logRequestDetails bool, logCompleteTime bool, bypassList ...BypassRequestLogging) func(next http.Handler) http.Handler {
+	return l.LoggerMiddlewareWithConfig(MiddlewareConfig{
+		LogRequestDetails: logRequestDetails,
+		LogCompleteTime:   logCompleteTime,
+		BypassList:        bypassList,
+		Filter:            filter,
+	})
+}
+
+func (l *Logger) LoggerMiddlewareWithConfig(config MiddlewareConfig) func(next http.Handler) http.Handler {
+	compiledBypassList := compileBypassPatterns(config.BypassList)
+
+	requestIDHeaders := config.RequestIDHeaders
+	if len(requestIDHeaders) == 0 {
+		requestIDHeaders = []string{defaultRequestIDHeader}
+	}
+
+	maxBodyBytes := config.MaxBodyBytes
+	if maxBodyBytes <= 0 {
+		maxBodyBytes = defaultMaxBodyBytes
+	}
+	detailGroups := config.RequestDetailGroups
+	if detailGroups == 0 {
+		detailGroups = AllRequestDetails
+	}
+	headerAllowList := config.HeaderAllowList
+	if len(headerAllowList) == 0 {
+		headerAllowList = defaultHeaderAllowList(detailGroups)
+	}
+
+	redactQueryParams := newRedactKeySet(config.RedactQueryParams)
+
+	bodyContentTypes := config.BodyContentTypes
+	if len(bodyContentTypes) == 0 {
+		bodyContentTypes = []string{defaultBodyMediaType}
+	}
 
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			startTime := time.Now()
 
-			requestId := l.GenerateRequestID()
-			r = r.WithContext(l.SetRequestID(r.Context(), requestId))
-
-			shouldSkipLogging := shouldBypassMiddlewareLogging(compiledBypassList, r.URL.Path, r.Method)
-
-			if logRequestDetails && !shouldSkipLogging {
-				requestData := map[string]any{
-					// Basic request info
-					"method":       r.Method,
-					"url":          r.URL.String(),
-					"path":         r.URL.Path,
-					"query_params": r.URL.RawQuery,
-					"protocol":     r.Proto,
-					"host":         r.Host,
-
-					// Client information
-					"user_ip":     getRealUserIP(r),
-					"remote_addr": r.RemoteAddr,
-					"user_agent":  r.Header.Get("User-Agent"),
-					"referer":     r.Header.Get("Referer"),
-
-					// Request size and content
-					"content_type":    r.Header.Get("Content-Type"),
-					"content_length":  r.ContentLength,
-					"accept":          r.Header.Get("Accept"),
-					"accept_encoding": r.Header.Get("Accept-Encoding"),
-					"accept_language": r.Header.Get("Accept-Language"),
-
-					// Security headers
-					"origin": r.Header.Get("Origin"),
-
-					// Load balancer / proxy headers
-					"x_forwarded_for":   r.Header.Get("X-Forwarded-For"),
-					"x_forwarded_proto": r.Header.Get("X-Forwarded-Proto"),
-					"x_forwarded_host":  r.Header.Get("X-Forwarded-Host"),
-					"x_real_ip":         r.Header.Get("X-Real-IP"),
-					"x_client_ip":       r.Header.Get("X-Client-IP"),
-				}
+			requestId, rejectedHeader := l.requestIDFromHeaders(r, requestIDHeaders)
+			if requestId == "" {
+				requestId = l.GenerateRequestID()
+			}
+			w.Header().Set(requestIDHeaders[0], requestId)
+
+			userIP := getRealUserIP(r)
+			ctx := l.SetRequestID(r.Context(), requestId)
+			ctx = l.SetUserIP(ctx, userIP)
+			ctx = ContextWithLogger(ctx, l)
+			var scope *RequestScope
+			if config.RequestScope {
+				scope = l.NewRequestScope(ctx)
+				ctx = context.WithValue(ctx, requestScopeKey, scope)
+			}
+			r = r.WithContext(ctx)
 
-				l.Infow(r.Context(), "Incoming request", "details", requestData)
+			if rejectedHeader != "" {
+				l.Debugw(ctx, "Rejected invalid inbound request ID", "header", rejectedHeader)
 			}
 
-			next.ServeHTTP(w, r)
+			shouldSkipLogging := shouldBypassMiddlewareLogging(compiledBypassList, r.URL.Path, r.Method) ||
+				(config.Filter != nil && config.Filter(r))
+
+			singleLine := config.LogMode == SingleLine
+
+			var requestData map[string]any
+			if config.LogRequestDetails && !shouldSkipLogging {
+				requestData = make(map[string]any)
+				if detailGroups&BasicInfo != 0 {
+					loggedURL, queryParams := redactQuery(r.URL, redactQueryParams)
+					requestData["method"] = r.Method
+					requestData["url"] = loggedURL
+					requestData["path"] = r.URL.Path
+					requestData["query_params"] = queryParams
+					requestData["protocol"] = r.Proto
+					requestData["host"] = r.Host
+				}
+				if detailGroups&ClientInfo != 0 {
+					requestData["user_ip"] = userIP
+					requestData["remote_addr"] = r.RemoteAddr
+				}
+				if detailGroups&ContentInfo != 0 {
+					requestData["content_length"] = r.ContentLength
+				}
+				addHeaders(requestData, r.Header, headerAllowList)
+				if config.LogTLS && r.TLS != nil {
+					requestData["tls_version"] = tls.VersionName(r.TLS.Version)
+					requestData["tls_cipher"] = tls.CipherSuiteName(r.TLS.CipherSuite)
+					requestData["tls_sni"] = r.TLS.ServerName
+				}
+				if config.OmitEmptyDetails {
+					omitEmptyDetails(requestData)
+				}
 
-			latency := time.Since(startTime)
+				if config.LogRequestBody && r.Body != nil && hasBodyMediaType(r, bodyContentTypes) {
+					if body, err := readRequestBody(r, maxBodyBytes); err == nil {
+						requestData["body"] = body
+					}
+				}
 
-			if logCompleteTime && !shouldSkipLogging {
-				l.Infow(r.Context(), "Request completed", "latency", latency)
+				if !singleLine {
+					l.Infow(r.Context(), "Incoming request", "details", requestData)
+				}
 			}
+
+			recorder := newStatusRecorder(w)
+			panicked := true
+
+			defer func() {
+				if panicked {
+					if !config.RecoverPanics {
+						return
+					}
+					l.recoverPanic(r.Context(), recorder, recover(), requestId, config.PanicResponse)
+				}
+
+				latency := time.Since(startTime)
+				if l.latencyObserver != nil {
+					l.observeLatency(r, latency)
+				}
+
+				if config.AccessLog && !shouldSkipLogging {
+					ctx, fields := l.accessLogEntry(r.Context(), AccessLogFields{
+						Method:       r.Method,
+						Path:         r.URL.Path,
+						Status:       recorder.status,
+						BytesWritten: recorder.bytesWritten,
+						Duration:     latency,
+						UserIP:       userIP,
+						RequestID:    requestId,
+						Referer:      r.Referer(),
+						UserAgent:    r.UserAgent(),
+					})
+					if scope != nil {
+						if scopeFields, ok := scope.finish(); ok {
+							fields = append(fields, scopeFields...)
+						}
+					}
+					l.Logw(ctx, InfoLevel, "access", fields...)
+				} else if (config.LogCompleteTime || singleLine) && !shouldSkipLogging {
+					level := InfoLevel
+					if !config.UniformCompletionLevel {
+						level = completionLevel(recorder.status)
+					}
+
+					fields := []any{
+						"latency", latency.String(),
+						"latency_ms", float64(latency) / float64(time.Millisecond),
+						"status", recorder.status,
+						"bytes_written", recorder.bytesWritten,
+					}
+					if len(config.ResponseHeaderAllowList) > 0 {
+						responseHeaders := make(map[string]any, len(config.ResponseHeaderAllowList))
+						addHeaders(responseHeaders, recorder.Header(), config.ResponseHeaderAllowList)
+						fields = append(fields, "response_headers", responseHeaders)
+					}
+					// The request times go in the details, which in single line
+					// mode also hold the request data
+					details := map[string]any{}
+					if singleLine {
+						fields = append([]any{"method", r.Method, "path", r.URL.Path}, fields...)
+						if requestData != nil {
+							details = requestData
+						}
+					}
+					details["request_start"] = startTime.Format(time.RFC3339Nano)
+					details["request_end"] = startTime.Add(latency).Format(time.RFC3339Nano)
+					fields = append(fields, "details", details)
+					if config.SlowRequestThreshold > 0 && latency > config.SlowRequestThreshold {
+						level = max(level, WarnLevel)
+						fields = append(fields, "slow", true)
+					}
+					if config.TagClientDisconnects && errors.Is(r.Context().Err(), context.Canceled) {
+						level = InfoLevel
+						fields = append(fields, "client_disconnected", true)
+					}
+					if scope != nil {
+						if scopeFields, ok := scope.finish(); ok {
+							fields = append(fields, scopeFields...)
+						}
+					}
+
+					l.Logw(r.Context(), level, "Request completed", fields...)
+				}
+			}()
+
+			next.ServeHTTP(recorder, r)
+			panicked = false
 		})
 	}
 }
 
+// recoverPanic logs a panic recovered from a handler and responds with 500 if
+// nothing has been written yet. http.ErrAbortHandler is re-panicked so the
+// server can abort the response as intended.
+func (l *Logger) recoverPanic(ctx context.Context, w *statusRecorder, rec any, requestId string, respond func(w http.ResponseWriter, requestID string)) {
+	// A nil value means the handler called runtime.Goexit, e.g. through
+	// t.FailNow, rather than panicking
+	if rec == nil {
+		return
+	}
+	if rec == http.ErrAbortHandler {
+		panic(rec)
+	}
+
+	l.Errorw(ctx, "Recovered from panic",
+		"panic", rec,
+		"stack", string(debug.Stack()),
+	)
+
+	if w.wroteHeader {
+		return
+	}
+	if respond != nil {
+		respond(w, requestId)
+		return
+	}
+	http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+}
+
+// JSONPanicResponse responds with 500 and {"error":"internal","request_id":"..."},
+// for MiddlewareConfig.PanicResponse.
+func JSONPanicResponse(w http.ResponseWriter, requestID string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusInternalServerError)
+	json.NewEncoder(w).Encode(map[string]string{
+		"error":      "internal",
+		"request_id": requestID,
+	})
+}
+
+func omitEmptyDetails(details map[string]any) {
+	for k, v := range details {
+		switch v {
+		case "", int64(0):
+			delete(details, k)
+		}
+	}
+}
+
+// observeLatency passes a completed request to the LatencyObserver with the
+// trace ID of its span, if any.
+func (l *Logger) observeLatency(r *http.Request, latency time.Duration) {
+	var traceID string
+	if spanCtx := trace.SpanContextFromContext(r.Context()); spanCtx.IsValid() {
+		traceID = spanCtx.TraceID().String()
+	}
+	l.latencyObserver(r.URL.Path, latency.Seconds(), traceID)
+}
+
+// requestIDFromHeaders returns the first valid request ID found in headers,
+// and the first header whose request ID was rejected as invalid, if any.
+func (l *Logger) requestIDFromHeaders(r *http.Request, headers []string) (string, string) {
+	rejectedHeader := ""
+	for _, header := range headers {
+		requestId := strings.TrimSpace(r.Header.Get(header))
+		if requestId == "" {
+			continue
+		}
+		if l.ValidRequestID(requestId) {
+			return requestId, rejectedHeader
+		}
+		if rejectedHeader == "" {
+			rejectedHeader = header
+		}
+	}
+	return "", rejectedHeader
+}
+
+// completionLevel maps a response status to the level of its completion log:
+// Info for 1xx-3xx, Warn for 4xx and Error for 5xx.
+func completionLevel(status int) Level {
+	switch {
+	case status >= http.StatusInternalServerError:
+		return ErrorLevel
+	case status >= http.StatusBadRequest:
+		return WarnLevel
+	default:
+		return InfoLevel
+	}
+}
+
 func getRealUserIP(r *http.Request) string {
 	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
 		ips := strings.Split(xff, ",")
@@ -3,11 +3,26 @@ package logger
 import (
 	"context"
 	"fmt"
+	"io"
+	"log"
+	"log/slog"
 	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"golang.org/x/time/rate"
 )
 
 var loggerInstance *Logger
 
+var (
+	defaultLogger     *Logger
+	defaultLoggerOnce sync.Once
+)
+
 func InitGlobalLogger(config LoggerConfig) error {
 	gLogger, err := NewLogger(config)
 	if err != nil {
@@ -20,7 +35,7 @@ func InitGlobalLogger(config LoggerConfig) error {
 
 func DestroyGlobalLogger() {
 	if loggerInstance != nil {
-		loggerInstance.Flush()
+		loggerInstance.Close()
 		loggerInstance = nil
 	}
 }
@@ -29,140 +44,373 @@ func GetGlobalLogger() *Logger {
 	return loggerInstance
 }
 
+// global returns the initialized global logger, falling back to a default
+// production logger so package-level calls made before InitGlobalLogger are
+// still written instead of panicking.
+func global() *Logger {
+	if l := loggerInstance; l != nil {
+		return l
+	}
+
+	defaultLoggerOnce.Do(func() {
+		defaultLogger, _ = NewLogger(LoggerConfig{})
+	})
+	return defaultLogger
+}
+
 func Debug(ctx context.Context, args ...any) {
-	msg := fmt.Sprint(args...)
-	combinedAttributes := loggerInstance.combineAttributes(ctx)
-	loggerInstance.logger.Debugw(msg, combinedAttributes...)
+	global().log(ctx, zapcore.DebugLevel, sprint(args...), nil)
 }
 
 func Info(ctx context.Context, args ...any) {
-	msg := fmt.Sprint(args...)
-	combinedAttributes := loggerInstance.combineAttributes(ctx)
-	loggerInstance.logger.Infow(msg, combinedAttributes...)
+	global().log(ctx, zapcore.InfoLevel, sprint(args...), nil)
 }
 
 func Warn(ctx context.Context, args ...any) {
-	msg := fmt.Sprint(args...)
-	combinedAttributes := loggerInstance.combineAttributes(ctx)
-	loggerInstance.logger.Warnw(msg, combinedAttributes...)
+	global().log(ctx, zapcore.WarnLevel, sprint(args...), nil)
 }
 
 func Error(ctx context.Context, args ...any) {
-	msg := fmt.Sprint(args...)
-	combinedAttributes := loggerInstance.combineAttributes(ctx)
-	loggerInstance.logger.Errorw(msg, combinedAttributes...)
+	global().log(ctx, zapcore.ErrorLevel, sprint(args...), nil)
 }
 
 func Panic(ctx context.Context, args ...any) {
-	msg := fmt.Sprint(args...)
-	combinedAttributes := loggerInstance.combineAttributes(ctx)
-	loggerInstance.logger.Panicw(msg, combinedAttributes...)
+	global().log(ctx, zapcore.PanicLevel, sprint(args...), nil)
 }
 
 func Fatal(ctx context.Context, args ...any) {
-	msg := fmt.Sprint(args...)
-	combinedAttributes := loggerInstance.combineAttributes(ctx)
-	loggerInstance.logger.Fatalw(msg, combinedAttributes...)
+	global().log(ctx, zapcore.FatalLevel, sprint(args...), nil)
 }
 
 func Debugf(ctx context.Context, template string, args ...any) {
-	msg := fmt.Sprintf(template, args...)
-	combinedAttributes := loggerInstance.combineAttributes(ctx)
-	loggerInstance.logger.Debugw(msg, combinedAttributes...)
+	global().log(ctx, zapcore.DebugLevel, fmt.Sprintf(template, args...), nil)
 }
 
 func Infof(ctx context.Context, template string, args ...any) {
-	msg := fmt.Sprintf(template, args...)
-	combinedAttributes := loggerInstance.combineAttributes(ctx)
-	loggerInstance.logger.Infow(msg, combinedAttributes...)
+	global().log(ctx, zapcore.InfoLevel, fmt.Sprintf(template, args...), nil)
 }
 
 func Warnf(ctx context.Context, template string, args ...any) {
-	msg := fmt.Sprintf(template, args...)
-	combinedAttributes := loggerInstance.combineAttributes(ctx)
-	loggerInstance.logger.Warnw(msg, combinedAttributes...)
+	global().log(ctx, zapcore.WarnLevel, fmt.Sprintf(template, args...), nil)
 }
 
 func Errorf(ctx context.Context, template string, args ...any) {
-	msg := fmt.Sprintf(template, args...)
-	combinedAttributes := loggerInstance.combineAttributes(ctx)
-	loggerInstance.logger.Errorw(msg, combinedAttributes...)
+	global().log(ctx, zapcore.ErrorLevel, fmt.Sprintf(template, args...), nil)
 }
 
 func Panicf(ctx context.Context, template string, args ...any) {
-	msg := fmt.Sprintf(template, args...)
-	combinedAttributes := loggerInstance.combineAttributes(ctx)
-	loggerInstance.logger.Panicw(msg, combinedAttributes...)
+	global().log(ctx, zapcore.PanicLevel, fmt.Sprintf(template, args...), nil)
 }
 
 func Fatalf(ctx context.Context, template string, args ...any) {
-	msg := fmt.Sprintf(template, args...)
-	combinedAttributes := loggerInstance.combineAttributes(ctx)
-	loggerInstance.logger.Fatalw(msg, combinedAttributes...)
+	global().log(ctx, zapcore.FatalLevel, fmt.Sprintf(template, args...), nil)
+}
+
+func Debugln(ctx context.Context, args ...any) {
+	global().log(ctx, zapcore.DebugLevel, sprintln(args...), nil)
+}
+
+func Infoln(ctx context.Context, args ...any) {
+	global().log(ctx, zapcore.InfoLevel, sprintln(args...), nil)
+}
+
+func Warnln(ctx context.Context, args ...any) {
+	global().log(ctx, zapcore.WarnLevel, sprintln(args...), nil)
+}
+
+func Errorln(ctx context.Context, args ...any) {
+	global().log(ctx, zapcore.ErrorLevel, sprintln(args...), nil)
+}
+
+func Panicln(ctx context.Context, args ...any) {
+	global().log(ctx, zapcore.PanicLevel, sprintln(args...), nil)
+}
+
+func Fatalln(ctx context.Context, args ...any) {
+	global().log(ctx, zapcore.FatalLevel, sprintln(args...), nil)
 }
 
 func Debugw(ctx context.Context, msg string, keysAndValues ...any) {
-	combinedAttributes := loggerInstance.combineAttributes(ctx, keysAndValues...)
-	loggerInstance.logger.Debugw(msg, combinedAttributes...)
+	global().log(ctx, zapcore.DebugLevel, msg, keysAndValues)
 }
 
 func Infow(ctx context.Context, msg string, keysAndValues ...any) {
-	combinedAttributes := loggerInstance.combineAttributes(ctx, keysAndValues...)
-	loggerInstance.logger.Infow(msg, combinedAttributes...)
+	global().log(ctx, zapcore.InfoLevel, msg, keysAndValues)
 }
 
 func Warnw(ctx context.Context, msg string, keysAndValues ...any) {
-	combinedAttributes := loggerInstance.combineAttributes(ctx, keysAndValues...)
-	loggerInstance.logger.Warnw(msg, combinedAttributes...)
+	global().log(ctx, zapcore.WarnLevel, msg, keysAndValues)
 }
 
 func Errorw(ctx context.Context, msg string, keysAndValues ...any) {
-	combinedAttributes := loggerInstance.combineAttributes(ctx, keysAndValues...)
-	loggerInstance.logger.Errorw(msg, combinedAttributes...)
+	global().log(ctx, zapcore.ErrorLevel, msg, keysAndValues)
 }
 
 func Panicw(ctx context.Context, msg string, keysAndValues ...any) {
-	combinedAttributes := loggerInstance.combineAttributes(ctx, keysAndValues...)
-	loggerInstance.logger.Panicw(msg, combinedAttributes...)
+	global().log(ctx, zapcore.PanicLevel, msg, keysAndValues)
 }
 
 func Fatalw(ctx context.Context, msg string, keysAndValues ...any) {
-	combinedAttributes := loggerInstance.combineAttributes(ctx, keysAndValues...)
-	loggerInstance.logger.Fatalw(msg, combinedAttributes...)
+	global().log(ctx, zapcore.FatalLevel, msg, keysAndValues)
+}
+
+func DebugwIf(ctx context.Context, cond bool, msg string, keysAndValues ...any) {
+	if cond {
+		global().log(ctx, zapcore.DebugLevel, msg, keysAndValues)
+	}
+}
+
+func InfowIf(ctx context.Context, cond bool, msg string, keysAndValues ...any) {
+	if cond {
+		global().log(ctx, zapcore.InfoLevel, msg, keysAndValues)
+	}
+}
+
+func WarnwIf(ctx context.Context, cond bool, msg string, keysAndValues ...any) {
+	if cond {
+		global().log(ctx, zapcore.WarnLevel, msg, keysAndValues)
+	}
+}
+
+func ErrorwIf(ctx context.Context, cond bool, msg string, keysAndValues ...any) {
+	if cond {
+		global().log(ctx, zapcore.ErrorLevel, msg, keysAndValues)
+	}
+}
+
+func Logw(ctx context.Context, level Level, msg string, keysAndValues ...any) {
+	l := global()
+	zl, err := level.zapLevel()
+	if err != nil {
+		zl = zapcore.InfoLevel
+	}
+	l.log(ctx, zl, msg, keysAndValues)
+}
+
+func LogMap(ctx context.Context, level Level, msg string, fields map[string]any) {
+	l := global()
+	zl, err := level.zapLevel()
+	if err != nil {
+		zl = zapcore.InfoLevel
+	}
+	l.log(ctx, zl, msg, mapToAttributes(fields))
+}
+
+func SetFixedKeyValue(key string, value any) {
+	global().SetFixedKeyValue(key, value)
+}
+
+func RemoveFixedKeyValue(key string) {
+	global().RemoveFixedKeyValue(key)
+}
+
+func Check(ctx context.Context, level Level, msg string) *LogEntry {
+	return global().check(ctx, level, msg)
+}
+
+func LogFields(ctx context.Context, level Level, msg string, fields ...zap.Field) {
+	if entry := global().check(ctx, level, msg); entry != nil {
+		entry.Write(fields...)
+	}
+}
+
+func ContextFields(ctx context.Context) []zap.Field {
+	return global().ContextFields(ctx)
+}
+
+func AccessLog(ctx context.Context, fields AccessLogFields) {
+	l := global()
+	ctx, keysAndValues := l.accessLogEntry(ctx, fields)
+	l.log(ctx, zapcore.InfoLevel, "access", keysAndValues)
+}
+
+func ErrorwRateLimited(ctx context.Context, key string, limit rate.Limit, msg string, keysAndValues ...any) {
+	l := global()
+	if keysAndValues, ok := l.rateLimit(key, limit, keysAndValues); ok {
+		l.log(ctx, zapcore.ErrorLevel, msg, keysAndValues)
+	}
+}
+
+func ErrorGrouped(ctx context.Context, fingerprint string, err error, msg string) {
+	global().log(ctx, zapcore.ErrorLevel, msg, errorGroupFields(fingerprint, err))
+}
+
+func WithContext(ctx context.Context) *ContextLogger {
+	return global().WithContext(ctx)
+}
+
+func WithFields(keysAndValues ...any) *Logger {
+	return global().WithFields(keysAndValues...)
+}
+
+func WithGroup(name string) *Logger {
+	return global().WithGroup(name)
+}
+
+func WithError(err error) *Logger {
+	return global().WithError(err)
 }
 
-func Flush() {
-	loggerInstance.Flush()
+func Flush() error {
+	return global().Flush()
+}
+
+func FlushIgnoringStderr() error {
+	return global().FlushIgnoringStderr()
+}
+
+func FlushOnSignal(ctx context.Context, signals ...os.Signal) {
+	global().FlushOnSignal(ctx, signals...)
+}
+
+func CloseOnSignal(ctx context.Context, fn func(os.Signal), signals ...os.Signal) {
+	global().CloseOnSignal(ctx, fn, signals...)
+}
+
+func Rotate() error {
+	return global().Rotate()
+}
+
+func RotateOnSignal(ctx context.Context, signals ...os.Signal) {
+	global().RotateOnSignal(ctx, signals...)
+}
+
+func SetLevel(level Level) {
+	global().SetLevel(level)
+}
+
+func GetLevel() Level {
+	return global().GetLevel()
+}
+
+func WithTemporaryLevel(level Level) (restore func()) {
+	return global().WithTemporaryLevel(level)
+}
+
+func LevelEnabled(level Level) bool {
+	return global().LevelEnabled(level)
+}
+
+func DebugEnabled() bool {
+	return global().DebugEnabled()
+}
+
+func LevelHandler() http.Handler {
+	return global().LevelHandler()
 }
 
 func IsDevMode() bool {
-	return loggerInstance.IsDevMode()
+	return global().IsDevMode()
 }
 
 func GenerateRequestID() string {
-	return loggerInstance.GenerateRequestID()
+	return global().GenerateRequestID()
+}
+
+func NewSubRequest(ctx context.Context, suffix string) context.Context {
+	return global().NewSubRequest(ctx, suffix)
+}
+
+func ValidRequestID(id string) bool {
+	return global().ValidRequestID(id)
 }
 
 func SetRequestID(ctx context.Context, requestID string) context.Context {
-	return loggerInstance.SetRequestID(ctx, requestID)
+	return global().SetRequestID(ctx, requestID)
 }
 
 func GetRequestID(ctx context.Context) (string, bool) {
-	return loggerInstance.GetRequestID(ctx)
+	return global().GetRequestID(ctx)
 }
 
 func SetUser(ctx context.Context, user any) context.Context {
-	return loggerInstance.SetUser(ctx, user)
+	return global().SetUser(ctx, user)
 }
 
 func GetUser(ctx context.Context) (any, bool) {
-	return loggerInstance.GetUser(ctx)
+	return global().GetUser(ctx)
+}
+
+func SetTenant(ctx context.Context, id string) context.Context {
+	return global().SetTenant(ctx, id)
+}
+
+func GetTenant(ctx context.Context) (string, bool) {
+	return global().GetTenant(ctx)
+}
+
+func SetUserIP(ctx context.Context, ip string) context.Context {
+	return global().SetUserIP(ctx, ip)
+}
+
+func GetUserIP(ctx context.Context) (string, bool) {
+	return global().GetUserIP(ctx)
+}
+
+func AppendField(ctx context.Context, key string, value any) context.Context {
+	return global().AppendField(ctx, key, value)
+}
+
+func WithWorkerID(ctx context.Context, id string) context.Context {
+	return global().WithWorkerID(ctx, id)
+}
+
+func GetWorkerID(ctx context.Context) (string, bool) {
+	return global().GetWorkerID(ctx)
+}
+
+func SetExtraField(ctx context.Context, field string, value any) context.Context {
+	return global().SetExtraField(ctx, field, value)
 }
 
 func GetExtraFields(ctx context.Context) (map[string]any, bool) {
-	return loggerInstance.GetExtraFields(ctx)
+	return global().GetExtraFields(ctx)
+}
+
+func DetachContext(ctx context.Context) context.Context {
+	return global().DetachContext(ctx)
+}
+
+func DetachContextWith(ctx context.Context, keys ...any) context.Context {
+	return global().DetachContextWith(ctx, keys...)
+}
+
+func WithTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	return global().WithTimeout(ctx, timeout)
+}
+
+func DetachGroupContext(ctx context.Context) (context.Context, func()) {
+	return global().DetachGroupContext(ctx)
+}
+
+func Go(ctx context.Context, fn func(ctx context.Context)) {
+	global().Go(ctx, fn)
+}
+
+func SlogHandler() slog.Handler {
+	return global().SlogHandler()
+}
+
+func Writer(level Level) io.Writer {
+	return global().Writer(level)
+}
+
+func StdLogAt(level Level) *log.Logger {
+	return global().StdLogAt(level)
+}
+
+func RoundTripper(base http.RoundTripper) http.RoundTripper {
+	return global().RoundTripper(base)
 }
 
 func LoggerMiddleware(logRequestDetails bool, logCompleteTime bool, bypassList ...BypassRequestLogging) func(next http.Handler) http.Handler {
-	return loggerInstance.LoggerMiddleware(logRequestDetails, logCompleteTime, bypassList...)
+	return global().LoggerMiddleware(logRequestDetails, logCompleteTime, bypassList...)
+}
+
+func LoggerMiddlewareWithFilter(filter func(r *http.Request) bool, logRequestDetails bool, logCompleteTime bool, bypassList ...BypassRequestLogging) func(next http.Handler) http.Handler {
+	return global().LoggerMiddlewareWithFilter(filter, logRequestDetails, logCompleteTime, bypassList...)
+}
+
+func LoggerMiddlewareWithConfig(config MiddlewareConfig) func(next http.Handler) http.Handler {
+	return global().LoggerMiddlewareWithConfig(config)
 }
@@ -0,0 +1,53 @@
+package logger_test
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/cyrus-wg/go-logger"
+)
+
+// TestGlobalBeforeInit re-runs itself in a child process, since the default
+// logger used before InitGlobalLogger writes to the stderr of the process.
+func TestGlobalBeforeInit(t *testing.T) {
+	if os.Getenv("LOGGER_TEST_BEFORE_INIT") == "1" {
+		logBeforeInit()
+		return
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestGlobalBeforeInit$")
+	cmd.Env = append(os.Environ(), "LOGGER_TEST_BEFORE_INIT=1")
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("package-level calls before InitGlobalLogger failed: %v\n%s", err, stderr.String())
+	}
+
+	output := stderr.String()
+	for _, want := range []string{"info before init", "infow before init", "errorf before init", "child before init", `"request_id":"req-1"`} {
+		if !strings.Contains(output, want) {
+			t.Errorf("default logger output lacks %q:\n%s", want, output)
+		}
+	}
+	if strings.Contains(output, "debug before init") {
+		t.Errorf("default logger wrote a Debug entry:\n%s", output)
+	}
+}
+
+func logBeforeInit() {
+	if logger.GetGlobalLogger() != nil {
+		panic("GetGlobalLogger returned a logger before InitGlobalLogger")
+	}
+
+	ctx := logger.SetRequestID(context.Background(), "req-1")
+	logger.Info(ctx, "info before init")
+	logger.Infow(ctx, "infow before init", "k", "v")
+	logger.Errorf(ctx, "errorf %s", "before init")
+	logger.Debug(ctx, "debug before init")
+	logger.WithFields("k", "v").Warn(ctx, "child before init")
+	logger.SetLevel(logger.InfoLevel)
+	logger.FlushIgnoringStderr()
+}
@@ -0,0 +1,564 @@
+package logger_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cyrus-wg/go-logger"
+)
+
+func TestWithFields(t *testing.T) {
+	l, buf := newLogger(t, logger.LoggerConfig{FixedKeyValues: map[string]any{"service": "api"}})
+	ctx := l.SetRequestID(context.Background(), "req-1")
+
+	billing := l.WithFields("component", "billing")
+	stripe := billing.WithFields("provider", "stripe")
+	billing.Info(ctx, "charge")
+	stripe.Info(ctx, "call")
+	l.Info(ctx, "plain")
+
+	tests := []struct {
+		message string
+		want    map[string]any
+		absent  []string
+	}{
+		{"charge", map[string]any{"component": "billing", "service": "api", "request_id": "req-1"}, []string{"provider"}},
+		{"call", map[string]any{"component": "billing", "provider": "stripe"}, nil},
+		{"plain", map[string]any{"service": "api"}, []string{"component", "provider"}},
+	}
+	lines := decodeLines(t, buf)
+	if len(lines) != len(tests) {
+		t.Fatalf("got %d lines, want %d:\n%s", len(lines), len(tests), buf)
+	}
+	for i, tt := range tests {
+		line := lines[i]
+		if line["message"] != tt.message {
+			t.Errorf("line %d message = %v, want %s", i, line["message"], tt.message)
+		}
+		for k, v := range tt.want {
+			if line[k] != v {
+				t.Errorf("%s: %s = %v, want %v", tt.message, k, line[k], v)
+			}
+		}
+		for _, k := range tt.absent {
+			if _, ok := line[k]; ok {
+				t.Errorf("%s: unexpected %s = %v", tt.message, k, line[k])
+			}
+		}
+	}
+}
+
+func TestNamed(t *testing.T) {
+	tests := []struct {
+		name   string
+		root   string
+		derive func(l *logger.Logger) *logger.Logger
+		want   any
+	}{
+		{"unnamed", "", func(l *logger.Logger) *logger.Logger { return l }, nil},
+		{"root name", "api", func(l *logger.Logger) *logger.Logger { return l }, "api"},
+		{"chained", "api", func(l *logger.Logger) *logger.Logger { return l.Named("billing").Named("stripe") }, "api.billing.stripe"},
+		{"named without root", "", func(l *logger.Logger) *logger.Logger { return l.Named("worker") }, "worker"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, buf := newLogger(t, logger.LoggerConfig{Name: tt.root})
+			tt.derive(l).Info(context.Background(), "msg")
+
+			if got := decodeLine(t, buf)["logger"]; got != tt.want {
+				t.Errorf("logger = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSampling(t *testing.T) {
+	tests := []struct {
+		name     string
+		sampling *logger.SamplingConfig
+		want     int
+	}{
+		{"production default", nil, 100},
+		{"configured", &logger.SamplingConfig{Initial: 5, Thereafter: 50}, 5 + 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			l, err := logger.NewLogger(logger.LoggerConfig{Output: &buf, Encoding: "json", Sampling: tt.sampling})
+			if err != nil {
+				t.Fatalf("NewLogger: %v", err)
+			}
+			for range 150 {
+				l.Info(context.Background(), "same message")
+			}
+
+			// The sampler counts per second, so a tick during the loop can
+			// let a few more lines through
+			if got := len(decodeLines(t, &buf)); got < tt.want || got >= 150 {
+				t.Errorf("logged %d of 150 identical lines, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTimeFormat(t *testing.T) {
+	tokyo := time.FixedZone("JST", 9*60*60)
+	tests := []struct {
+		name     string
+		format   string
+		zone     *time.Location
+		layout   string
+		wantZone string
+	}{
+		{"layout and UTC", time.RFC1123, time.UTC, time.RFC1123, "UTC"},
+		{"zone only", "", tokyo, "2006-01-02T15:04:05.000Z0700", "+0900"},
+		{"layout only", time.DateTime + " MST", nil, time.DateTime + " MST", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, buf := newLogger(t, logger.LoggerConfig{TimeFormat: tt.format, TimeZone: tt.zone})
+			before := time.Now().Truncate(time.Second)
+			l.Info(context.Background(), "msg")
+
+			timestamp, _ := decodeLine(t, buf)["@timestamp"].(string)
+			logged, err := time.Parse(tt.layout, timestamp)
+			if err != nil {
+				t.Fatalf("@timestamp %q does not match layout %q: %v", timestamp, tt.layout, err)
+			}
+			if tt.wantZone != "" && !strings.Contains(timestamp, tt.wantZone) {
+				t.Errorf("@timestamp %q is not in zone %s", timestamp, tt.wantZone)
+			}
+			if logged.Before(before.Add(-time.Second)) || logged.After(time.Now().Add(time.Second)) {
+				t.Errorf("@timestamp %v is not the current time", logged)
+			}
+		})
+	}
+}
+
+func TestStacktrace(t *testing.T) {
+	tests := []struct {
+		name   string
+		config logger.LoggerConfig
+		warn   bool
+		error  bool
+	}{
+		{"default", logger.LoggerConfig{}, false, true},
+		{"warn threshold", logger.LoggerConfig{StacktraceLevel: logger.WarnLevel}, true, true},
+		{"panic threshold", logger.LoggerConfig{StacktraceLevel: logger.PanicLevel}, false, false},
+		{"disabled", logger.LoggerConfig{DisableStacktrace: true, StacktraceLevel: logger.WarnLevel}, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, buf := newLogger(t, tt.config)
+			l.Warn(context.Background(), "warn")
+			l.Error(context.Background(), "error")
+
+			lines := decodeLines(t, buf)
+			if len(lines) != 2 {
+				t.Fatalf("got %d lines, want 2", len(lines))
+			}
+			for i, want := range []bool{tt.warn, tt.error} {
+				stacktrace, _ := lines[i]["stacktrace"].(string)
+				if got := stacktrace != ""; got != want {
+					t.Errorf("%s has stacktrace = %t, want %t", lines[i]["message"], got, want)
+				}
+				if stacktrace != "" && !strings.Contains(stacktrace, "TestStacktrace") {
+					t.Errorf("stacktrace does not start at the caller:\n%s", stacktrace)
+				}
+			}
+		})
+	}
+}
+
+// stackError formats with a stack trace under %+v, like github.com/pkg/errors.
+type stackError struct{ msg string }
+
+func (e stackError) Error() string { return e.msg }
+
+func (e stackError) Format(f fmt.State, verb rune) {
+	if verb == 'v' && f.Flag('+') {
+		fmt.Fprintf(f, "%s\nmain.charge\n\tcharge.go:42", e.msg)
+		return
+	}
+	fmt.Fprint(f, e.msg)
+}
+
+func TestWithError(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		wantError   any
+		wantVerbose any
+	}{
+		{"plain", errors.New("declined"), "declined", nil},
+		{"with stack", stackError{"declined"}, "declined", "declined\nmain.charge\n\tcharge.go:42"},
+		{"nil", nil, nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, buf := newLogger(t, logger.LoggerConfig{DisableStacktrace: true})
+			child := l.WithError(tt.err)
+			if tt.err == nil && child != l {
+				t.Error("WithError(nil) returned a new logger")
+			}
+			child.Error(context.Background(), "failed to process")
+
+			line := decodeLine(t, buf)
+			if line["error"] != tt.wantError {
+				t.Errorf("error = %v, want %v", line["error"], tt.wantError)
+			}
+			if line["errorVerbose"] != tt.wantVerbose {
+				t.Errorf("errorVerbose = %q, want %q", line["errorVerbose"], tt.wantVerbose)
+			}
+		})
+	}
+}
+
+func TestClone(t *testing.T) {
+	l, buf := newLogger(t, logger.LoggerConfig{FixedKeyValues: map[string]any{"service": "api", "color": "blue"}})
+	clone := l.Clone()
+	clone.SetFixedKeyValue("color", "green")
+	clone.SetFixedKeyValue("request_scoped", true)
+	l.RemoveFixedKeyValue("service")
+
+	ctx := context.Background()
+	l.Info(ctx, "original")
+	clone.Info(ctx, "clone")
+
+	lines := decodeLines(t, buf)
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2", len(lines))
+	}
+	tests := []struct {
+		line map[string]any
+		want map[string]any
+	}{
+		{lines[0], map[string]any{"color": "blue", "service": nil, "request_scoped": nil}},
+		{lines[1], map[string]any{"color": "green", "service": "api", "request_scoped": true}},
+	}
+	for _, tt := range tests {
+		for k, v := range tt.want {
+			if tt.line[k] != v {
+				t.Errorf("%s: %s = %v, want %v", tt.line["message"], k, tt.line[k], v)
+			}
+		}
+	}
+}
+
+func TestFixedKeyValuesConcurrent(t *testing.T) {
+	l, buf := newLogger(t, logger.LoggerConfig{})
+	child := l.WithFields("component", "billing")
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			key := fmt.Sprintf("key_%d", i%2)
+			for j := range 100 {
+				switch {
+				case i%4 == 0:
+					l.SetFixedKeyValue(key, j)
+				case i%4 == 1:
+					l.RemoveFixedKeyValue(key)
+				default:
+					child.Info(ctx, "msg")
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	l.SetFixedKeyValue("deployment_color", "green")
+	buf.Reset()
+	child.Info(ctx, "after")
+	if got := decodeLine(t, buf)["deployment_color"]; got != "green" {
+		t.Errorf("deployment_color = %v, want green on a child created earlier", got)
+	}
+}
+
+// keyOrder returns the top-level keys of a JSON object in the order written.
+func keyOrder(t *testing.T, line []byte) []string {
+	t.Helper()
+	dec := json.NewDecoder(bytes.NewReader(line))
+	if _, err := dec.Token(); err != nil {
+		t.Fatalf("invalid JSON line %q: %v", line, err)
+	}
+	var keys []string
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			t.Fatalf("invalid JSON line %q: %v", line, err)
+		}
+		keys = append(keys, key.(string))
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			t.Fatalf("invalid JSON line %q: %v", line, err)
+		}
+	}
+	return keys
+}
+
+func TestFieldOrder(t *testing.T) {
+	l, buf := newLogger(t, logger.LoggerConfig{
+		DisableTimestamp: true,
+		DisableCaller:    true,
+		FixedKeyValues:   map[string]any{"zone": "eu-1", "app": "api", "env": "prod"},
+		ExtraFields:      []string{"tenant", "locale", "plan"},
+	})
+	ctx := l.SetRequestID(context.Background(), "req-1")
+	ctx = l.SetUser(ctx, "alice")
+	ctx = l.SetExtraField(ctx, "tenant", "acme")
+	ctx = l.SetExtraField(ctx, "plan", "pro")
+	ctx = l.SetExtraField(ctx, "locale", "en")
+
+	want := []string{"level", "message", "app", "env", "zone", "request_id", "user", "locale", "plan", "tenant", "component", "z", "a"}
+	for range 20 {
+		buf.Reset()
+		l.WithFields("component", "billing").Infow(ctx, "msg", "z", 1, "a", 2)
+		if got := keyOrder(t, bytes.TrimSpace(buf.Bytes())); !slices.Equal(got, want) {
+			t.Fatalf("key order = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestDisableTimestampAndCaller(t *testing.T) {
+	tests := []struct {
+		name          string
+		config        logger.LoggerConfig
+		wantTimestamp bool
+		wantCaller    bool
+	}{
+		{"default", logger.LoggerConfig{}, true, true},
+		{"timestamp disabled", logger.LoggerConfig{DisableTimestamp: true}, false, true},
+		{"caller disabled", logger.LoggerConfig{DisableCaller: true}, true, false},
+		{"both disabled", logger.LoggerConfig{DisableTimestamp: true, DisableCaller: true}, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, buf := newLogger(t, tt.config)
+			l.Info(context.Background(), "msg")
+
+			line := decodeLine(t, buf)
+			if _, got := line["@timestamp"]; got != tt.wantTimestamp {
+				t.Errorf("has @timestamp = %t, want %t", got, tt.wantTimestamp)
+			}
+			if _, got := line["caller"]; got != tt.wantCaller {
+				t.Errorf("has caller = %t, want %t", got, tt.wantCaller)
+			}
+		})
+	}
+}
+
+func TestHostnameAndProcessID(t *testing.T) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		t.Fatalf("Hostname: %v", err)
+	}
+	tests := []struct {
+		name         string
+		config       logger.LoggerConfig
+		hostnameErr  error
+		wantHostname any
+		wantPID      any
+	}{
+		{"disabled", logger.LoggerConfig{}, nil, nil, nil},
+		{"both", logger.LoggerConfig{IncludeHostname: true, IncludeProcessID: true}, nil, hostname, float64(os.Getpid())},
+		{"hostname error", logger.LoggerConfig{IncludeHostname: true}, errors.New("uname failed"), "unknown", nil},
+		{"fixed value wins", logger.LoggerConfig{IncludeHostname: true, FixedKeyValues: map[string]any{"hostname": "pod-1"}}, nil, "pod-1", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.hostnameErr != nil {
+				defer logger.SetHostnameFunc(func() (string, error) { return "", tt.hostnameErr })()
+			}
+			l, buf := newLogger(t, tt.config)
+			l.Info(context.Background(), "msg")
+
+			line := decodeLine(t, buf)
+			if line["hostname"] != tt.wantHostname {
+				t.Errorf("hostname = %v, want %v", line["hostname"], tt.wantHostname)
+			}
+			if line["pid"] != tt.wantPID {
+				t.Errorf("pid = %v, want %v", line["pid"], tt.wantPID)
+			}
+		})
+	}
+}
+
+func TestWithGroup(t *testing.T) {
+	tests := []struct {
+		name   string
+		derive func(l *logger.Logger) *logger.Logger
+		want   string
+	}{
+		{
+			name:   "call fields",
+			derive: func(l *logger.Logger) *logger.Logger { return l.WithGroup("http") },
+			want:   `{"level":"INFO","message":"msg","service":"api","request_id":"req-1","http":{"status":200}}`,
+		},
+		{
+			name:   "bound after group",
+			derive: func(l *logger.Logger) *logger.Logger { return l.WithGroup("http").WithFields("method", "GET") },
+			want:   `{"level":"INFO","message":"msg","service":"api","request_id":"req-1","http":{"method":"GET","status":200}}`,
+		},
+		{
+			name:   "bound before group",
+			derive: func(l *logger.Logger) *logger.Logger { return l.WithFields("component", "api").WithGroup("http") },
+			want:   `{"level":"INFO","message":"msg","service":"api","request_id":"req-1","component":"api","http":{"status":200}}`,
+		},
+		{
+			name:   "nested groups",
+			derive: func(l *logger.Logger) *logger.Logger { return l.WithGroup("http").WithGroup("response") },
+			want:   `{"level":"INFO","message":"msg","service":"api","request_id":"req-1","http":{"response":{"status":200}}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, buf := newLogger(t, logger.LoggerConfig{
+				DisableTimestamp: true,
+				DisableCaller:    true,
+				FixedKeyValues:   map[string]any{"service": "api"},
+			})
+			tt.derive(l).Infow(l.SetRequestID(context.Background(), "req-1"), "msg", "status", 200)
+
+			if got := strings.TrimSpace(buf.String()); got != tt.want {
+				t.Errorf("logged %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithGroupWithoutFields(t *testing.T) {
+	l, buf := newLogger(t, logger.LoggerConfig{DisableTimestamp: true, DisableCaller: true})
+	g := l.WithFields("component", "api").WithGroup("http").WithGroup("response")
+	g.Info(context.Background(), "msg")
+	g.LogFields(context.Background(), logger.InfoLevel, "msg")
+	g.WithFields().Infow(context.Background(), "msg")
+
+	want := `{"level":"INFO","message":"msg","component":"api"}`
+	for _, got := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if got != want {
+			t.Errorf("logged %s, want %s", got, want)
+		}
+	}
+}
+
+func TestLogMap(t *testing.T) {
+	tests := []struct {
+		name      string
+		level     logger.Level
+		fields    map[string]any
+		wantLevel string
+		wantKeys  []string
+	}{
+		{"sorted", logger.WarnLevel, map[string]any{"zone": "eu-1", "amount": 42, "currency": "EUR"}, "WARN", []string{"level", "message", "amount", "currency", "zone"}},
+		{"empty", logger.InfoLevel, nil, "INFO", []string{"level", "message"}},
+		{"invalid level", logger.Level(42), map[string]any{"k": "v"}, "INFO", []string{"level", "message", "k"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, buf := newLogger(t, logger.LoggerConfig{DisableTimestamp: true, DisableCaller: true})
+			for range 5 {
+				buf.Reset()
+				l.LogMap(context.Background(), tt.level, "charge", tt.fields)
+
+				if got := keyOrder(t, bytes.TrimSpace(buf.Bytes())); !slices.Equal(got, tt.wantKeys) {
+					t.Fatalf("key order = %v, want %v", got, tt.wantKeys)
+				}
+			}
+			line := decodeLine(t, buf)
+			if line["level"] != tt.wantLevel {
+				t.Errorf("level = %v, want %s", line["level"], tt.wantLevel)
+			}
+			for k, v := range tt.fields {
+				if fmt.Sprint(line[k]) != fmt.Sprint(v) {
+					t.Errorf("%s = %v, want %v", k, line[k], v)
+				}
+			}
+		})
+	}
+}
+
+func TestLn(t *testing.T) {
+	tests := []struct {
+		name string
+		args []any
+	}{
+		{"strings", []any{"charge", "created"}},
+		{"mixed", []any{"charge", 42, "for", 3.5, true}},
+		{"numbers", []any{1, 2, 3}},
+		{"error", []any{"failed:", errors.New("declined")}},
+		{"single", []any{"done"}},
+		{"empty", nil},
+		{"trailing newline", []any{"line\n"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, buf := newLogger(t, logger.LoggerConfig{DisableStacktrace: true})
+			ctx := context.Background()
+			l.Infoln(ctx, tt.args...)
+			l.Errorln(ctx, tt.args...)
+
+			want := strings.TrimSuffix(fmt.Sprintln(tt.args...), "\n")
+			lines := decodeLines(t, buf)
+			if len(lines) != 2 {
+				t.Fatalf("got %d lines, want 2", len(lines))
+			}
+			for _, line := range lines {
+				if line["message"] != want {
+					t.Errorf("%v message = %q, want %q", line["level"], line["message"], want)
+				}
+			}
+		})
+	}
+}
+
+func TestLogIf(t *testing.T) {
+	methods := []struct {
+		name  string
+		log   func(l *logger.Logger, ctx context.Context, cond bool)
+		level string
+	}{
+		{"DebugwIf", func(l *logger.Logger, ctx context.Context, cond bool) { l.DebugwIf(ctx, cond, "msg", "k", "v") }, "DEBUG"},
+		{"InfowIf", func(l *logger.Logger, ctx context.Context, cond bool) { l.InfowIf(ctx, cond, "msg", "k", "v") }, "INFO"},
+		{"WarnwIf", func(l *logger.Logger, ctx context.Context, cond bool) { l.WarnwIf(ctx, cond, "msg", "k", "v") }, "WARN"},
+		{"ErrorwIf", func(l *logger.Logger, ctx context.Context, cond bool) { l.ErrorwIf(ctx, cond, "msg", "k", "v") }, "ERROR"},
+	}
+	for _, m := range methods {
+		for _, cond := range []bool{true, false} {
+			t.Run(fmt.Sprintf("%s/%t", m.name, cond), func(t *testing.T) {
+				extracted := 0
+				l, buf := newLogger(t, logger.LoggerConfig{
+					DisableStacktrace: true,
+					FieldExtractors: []logger.FieldExtractor{func(context.Context) (string, any, bool) {
+						extracted++
+						return "", nil, false
+					}},
+				})
+				m.log(l, context.Background(), cond)
+
+				lines := decodeLines(t, buf)
+				if !cond {
+					if len(lines) != 0 || extracted != 0 {
+						t.Errorf("cond false logged %d lines and ran the extractors %d times, want none", len(lines), extracted)
+					}
+					return
+				}
+				if len(lines) != 1 || lines[0]["level"] != m.level || lines[0]["k"] != "v" {
+					t.Errorf("cond true logged %v, want one %s line with k=v", lines, m.level)
+				}
+			})
+		}
+	}
+}
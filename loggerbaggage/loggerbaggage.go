@@ -0,0 +1,83 @@
+// Package loggerbaggage logs OpenTelemetry (W3C) baggage carried in the
+// context, such as tenant or feature-flag members, without the main logger
+// package depending on the baggage API.
+package loggerbaggage
+
+import (
+	"cmp"
+	"context"
+	"slices"
+
+	"github.com/cyrus-wg/go-logger"
+	"go.opentelemetry.io/otel/baggage"
+)
+
+// Key is the field NestedExtractor logs the baggage members under.
+const Key = "baggage"
+
+// Extractor returns a logger.MultiFieldExtractor, for
+// logger.LoggerConfig.MultiFieldExtractors, that logs each baggage member in
+// the context as a top-level field, e.g. {"tenant":"acme"}. When keys are
+// given only those members are logged, in that order; otherwise all members
+// are logged sorted by key.
+func Extractor(keys ...string) logger.MultiFieldExtractor {
+	return PrefixedExtractor("", keys...)
+}
+
+// PrefixedExtractor is like Extractor but adds prefix to each field key, e.g.
+// "baggage." logs {"baggage.tenant":"acme"}, so members cannot collide with
+// the other fields of the entry.
+func PrefixedExtractor(prefix string, keys ...string) logger.MultiFieldExtractor {
+	return func(ctx context.Context) []any {
+		var keysAndValues []any
+		for _, member := range members(ctx, keys) {
+			keysAndValues = append(keysAndValues, prefix+member.Key(), member.Value())
+		}
+		return keysAndValues
+	}
+}
+
+// NestedExtractor returns a logger.FieldExtractor, for
+// logger.LoggerConfig.FieldExtractors, that logs the baggage members in the
+// context as an object under "baggage", e.g. {"baggage":{"tenant":"acme"}}.
+// When keys are given only those members are logged. Nothing is added when no
+// member matches.
+func NestedExtractor(keys ...string) logger.FieldExtractor {
+	return func(ctx context.Context) (string, any, bool) {
+		matched := members(ctx, keys)
+		if len(matched) == 0 {
+			return "", nil, false
+		}
+
+		values := make(map[string]string, len(matched))
+		for _, member := range matched {
+			values[member.Key()] = member.Value()
+		}
+		return Key, values, true
+	}
+}
+
+// members returns the baggage members in ctx whose key is in keys, in the
+// order of keys, or all members sorted by key when keys is empty.
+func members(ctx context.Context, keys []string) []baggage.Member {
+	bag := baggage.FromContext(ctx)
+	if bag.Len() == 0 {
+		return nil
+	}
+
+	if len(keys) == 0 {
+		all := bag.Members()
+		slices.SortFunc(all, func(a, b baggage.Member) int {
+			return cmp.Compare(a.Key(), b.Key())
+		})
+		return all
+	}
+
+	var matched []baggage.Member
+	for _, key := range keys {
+		if member := bag.Member(key); member.Key() != "" {
+			matched = append(matched, member)
+		}
+	}
+	return matched
+}
@@ -0,0 +1,139 @@
+package loggerbaggage_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"maps"
+	"testing"
+
+	"github.com/cyrus-wg/go-logger"
+	"github.com/cyrus-wg/go-logger/loggerbaggage"
+	"github.com/cyrus-wg/go-logger/loggertest"
+	"go.opentelemetry.io/otel/baggage"
+)
+
+func newBaggageContext(t *testing.T) context.Context {
+	t.Helper()
+	bag, err := baggage.Parse("tenant=acme,flag.checkout=v2,session=secret")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	return baggage.ContextWithBaggage(context.Background(), bag)
+}
+
+func logLine(t *testing.T, ctx context.Context, config logger.LoggerConfig) (map[string]any, *bytes.Buffer) {
+	t.Helper()
+	l, buf, err := loggertest.NewLoggerWithBufferConfig(config)
+	if err != nil {
+		t.Fatalf("NewLoggerWithBufferConfig: %v", err)
+	}
+	l.Info(ctx, "msg")
+
+	var line map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatalf("invalid JSON line %q: %v", buf, err)
+	}
+	return line, buf
+}
+
+func TestExtractor(t *testing.T) {
+	withBaggage := newBaggageContext(t)
+
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		extractor logger.MultiFieldExtractor
+		want      map[string]any
+		absent    []string
+	}{
+		{
+			name:      "all members",
+			ctx:       withBaggage,
+			extractor: loggerbaggage.Extractor(),
+			want:      map[string]any{"tenant": "acme", "flag.checkout": "v2", "session": "secret"},
+		},
+		{
+			name:      "allow list",
+			ctx:       withBaggage,
+			extractor: loggerbaggage.Extractor("tenant", "flag.checkout", "region"),
+			want:      map[string]any{"tenant": "acme", "flag.checkout": "v2"},
+			absent:    []string{"session", "region"},
+		},
+		{
+			name:      "prefix",
+			ctx:       withBaggage,
+			extractor: loggerbaggage.PrefixedExtractor("baggage.", "tenant"),
+			want:      map[string]any{"baggage.tenant": "acme"},
+			absent:    []string{"tenant", "baggage.session"},
+		},
+		{
+			name:      "no baggage",
+			ctx:       context.Background(),
+			extractor: loggerbaggage.Extractor(),
+			absent:    []string{"tenant", "flag.checkout", "session"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			line, _ := logLine(t, tt.ctx, logger.LoggerConfig{
+				MultiFieldExtractors: []logger.MultiFieldExtractor{tt.extractor},
+			})
+
+			for k, v := range tt.want {
+				if line[k] != v {
+					t.Errorf("%s = %v, want %v", k, line[k], v)
+				}
+			}
+			for _, k := range tt.absent {
+				if _, ok := line[k]; ok {
+					t.Errorf("unexpected %s = %v", k, line[k])
+				}
+			}
+			if _, ok := line[loggerbaggage.Key]; ok {
+				t.Errorf("members nested under %s, want top-level fields", loggerbaggage.Key)
+			}
+		})
+	}
+}
+
+func TestExtractorSortsMembers(t *testing.T) {
+	_, buf := logLine(t, newBaggageContext(t), logger.LoggerConfig{
+		MultiFieldExtractors: []logger.MultiFieldExtractor{loggerbaggage.Extractor()},
+	})
+
+	flag := bytes.Index(buf.Bytes(), []byte(`"flag.checkout"`))
+	session := bytes.Index(buf.Bytes(), []byte(`"session"`))
+	tenant := bytes.Index(buf.Bytes(), []byte(`"tenant"`))
+	if flag < 0 || !(flag < session && session < tenant) {
+		t.Errorf("members are not logged sorted by key: %s", buf)
+	}
+}
+
+func TestNestedExtractor(t *testing.T) {
+	withBaggage := newBaggageContext(t)
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		keys []string
+		want map[string]any
+	}{
+		{"all members", withBaggage, nil, map[string]any{"tenant": "acme", "flag.checkout": "v2", "session": "secret"}},
+		{"allow list", withBaggage, []string{"tenant", "flag.checkout", "region"}, map[string]any{"tenant": "acme", "flag.checkout": "v2"}},
+		{"no matching member", withBaggage, []string{"region"}, nil},
+		{"no baggage", context.Background(), nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			line, _ := logLine(t, tt.ctx, logger.LoggerConfig{
+				FieldExtractors: []logger.FieldExtractor{loggerbaggage.NestedExtractor(tt.keys...)},
+			})
+
+			got, ok := line[loggerbaggage.Key].(map[string]any)
+			if ok != (tt.want != nil) || !maps.Equal(got, tt.want) {
+				t.Errorf("%s = %v, want %v", loggerbaggage.Key, line[loggerbaggage.Key], tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+// Package loggergrpc provides gRPC server interceptors that add the same
+// request ID tracing and completion logging as logger.LoggerMiddleware.
+//
+// The interceptors are package functions taking the logger, e.g.
+// UnaryServerInterceptor(l), rather than methods on *logger.Logger. A method
+// would make the logger package import gRPC, so every user of it would depend
+// on gRPC; keeping them here leaves that dependency to services that use it.
+package loggergrpc
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/cyrus-wg/go-logger"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// RequestIDMetadataKey is the incoming metadata key whose value is reused as
+// the request ID instead of generating a new one.
+const RequestIDMetadataKey = "x-request-id"
+
+// UnaryServerInterceptor returns an interceptor that adds a request ID to the
+// context of each unary call and logs its completion with l.
+func UnaryServerInterceptor(l *logger.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		startTime := time.Now()
+		ctx = withRequestContext(l, ctx)
+
+		resp, err := handler(ctx, req)
+
+		logCompletion(l, ctx, info.FullMethod, time.Since(startTime), err)
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor is like UnaryServerInterceptor for streaming calls.
+func StreamServerInterceptor(l *logger.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		startTime := time.Now()
+		ctx := withRequestContext(l, ss.Context())
+
+		err := handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+
+		logCompletion(l, ctx, info.FullMethod, time.Since(startTime), err)
+		return err
+	}
+}
+
+// serverStream overrides the context of a grpc.ServerStream so handlers see
+// the request ID.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+func withRequestContext(l *logger.Logger, ctx context.Context) context.Context {
+	requestId := ""
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(RequestIDMetadataKey); len(values) > 0 {
+			requestId = values[0]
+		}
+	}
+	rejected := requestId != "" && !l.ValidRequestID(requestId)
+	if requestId == "" || rejected {
+		requestId = l.GenerateRequestID()
+	}
+	ctx = l.SetRequestID(ctx, requestId)
+	if rejected {
+		l.Debugw(ctx, "Rejected invalid inbound request ID", "metadata_key", RequestIDMetadataKey)
+	}
+
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		ip, _, err := net.SplitHostPort(p.Addr.String())
+		if err != nil {
+			ip = p.Addr.String()
+		}
+		ctx = l.SetUserIP(ctx, ip)
+	}
+
+	return ctx
+}
+
+func logCompletion(l *logger.Logger, ctx context.Context, method string, latency time.Duration, err error) {
+	code := status.Code(err)
+	keysAndValues := []any{
+		"grpc_method", method,
+		"grpc_code", code.String(),
+		"latency", latency.String(),
+		"latency_ms", float64(latency) / float64(time.Millisecond),
+	}
+	if err != nil {
+		keysAndValues = append(keysAndValues, "error", err)
+		if st, ok := status.FromError(err); ok {
+			keysAndValues = append(keysAndValues, "grpc_message", st.Message())
+			if details := statusDetails(st); len(details) > 0 {
+				keysAndValues = append(keysAndValues, "grpc_details", details)
+			}
+		}
+	}
+
+	l.Logw(ctx, codeToLevel(code), "gRPC call completed", keysAndValues...)
+}
+
+// maxDetailsBytes bounds the total size of the status details logged for a
+// failed call.
+const maxDetailsBytes = 4096
+
+// statusDetails returns the details of st as protojson strings. Details past
+// maxDetailsBytes are replaced by a single truncation marker.
+func statusDetails(st *status.Status) []string {
+	var details []string
+	size := 0
+	for _, detail := range st.Proto().GetDetails() {
+		encoded, err := protojson.Marshal(detail)
+		if err != nil {
+			continue
+		}
+		if size += len(encoded); size > maxDetailsBytes {
+			details = append(details, "...[truncated]")
+			break
+		}
+		details = append(details, string(encoded))
+	}
+	return details
+}
+
+// codeToLevel maps a gRPC status code to a log level: client-side problems
+// are Info or Warn, server-side failures are Error.
+func codeToLevel(code codes.Code) logger.Level {
+	switch code {
+	case codes.OK, codes.Canceled, codes.InvalidArgument, codes.NotFound,
+		codes.AlreadyExists, codes.Unauthenticated:
+		return logger.InfoLevel
+	case codes.DeadlineExceeded, codes.PermissionDenied, codes.ResourceExhausted,
+		codes.FailedPrecondition, codes.Aborted, codes.OutOfRange, codes.Unavailable:
+		return logger.WarnLevel
+	default:
+		return logger.ErrorLevel
+	}
+}
@@ -0,0 +1,196 @@
+package loggergrpc_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/cyrus-wg/go-logger"
+	"github.com/cyrus-wg/go-logger/loggergrpc"
+	"github.com/cyrus-wg/go-logger/loggertest"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func decodeLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var lines []map[string]any
+	scanner := bufio.NewScanner(buf)
+	for scanner.Scan() {
+		var line map[string]any
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("invalid JSON line %q: %v", scanner.Text(), err)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	tests := []struct {
+		name          string
+		md            metadata.MD
+		err           error
+		wantRequestID string
+		wantCode      string
+		wantLevel     string
+	}{
+		{"reuses request ID", metadata.Pairs("x-request-id", "req-1"), nil, "req-1", "OK", "INFO"},
+		{"generates request ID", nil, nil, "", "OK", "INFO"},
+		{"rejects invalid request ID", metadata.Pairs("x-request-id", "bad id\n"), nil, "", "OK", "INFO"},
+		{"not found", nil, status.Error(codes.NotFound, "missing"), "", "NotFound", "INFO"},
+		{"unavailable", nil, status.Error(codes.Unavailable, "down"), "", "Unavailable", "WARN"},
+		{"internal", nil, status.Error(codes.Internal, "boom"), "", "Internal", "ERROR"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, buf, err := loggertest.NewLoggerWithBufferConfig(logger.LoggerConfig{Level: logger.InfoLevel, DisableStacktrace: true})
+			if err != nil {
+				t.Fatalf("NewLoggerWithBufferConfig: %v", err)
+			}
+			ctx := context.Background()
+			if tt.md != nil {
+				ctx = metadata.NewIncomingContext(ctx, tt.md)
+			}
+
+			var handlerRequestID string
+			handler := func(ctx context.Context, req any) (any, error) {
+				handlerRequestID, _ = l.GetRequestID(ctx)
+				return "resp", tt.err
+			}
+			info := &grpc.UnaryServerInfo{FullMethod: "/orders.Orders/Get"}
+			resp, err := loggergrpc.UnaryServerInterceptor(l)(ctx, "req", info, handler)
+			if resp != "resp" || err != tt.err {
+				t.Errorf("interceptor returned %v, %v, want the handler's result", resp, err)
+			}
+
+			lines := decodeLines(t, buf)
+			if len(lines) != 1 {
+				t.Fatalf("got %d lines, want 1:\n%s", len(lines), buf)
+			}
+			line := lines[0]
+			if tt.wantRequestID != "" && handlerRequestID != tt.wantRequestID {
+				t.Errorf("handler request ID = %q, want %q", handlerRequestID, tt.wantRequestID)
+			}
+			if handlerRequestID == "" || line["request_id"] != handlerRequestID {
+				t.Errorf("request_id = %v, handler saw %q", line["request_id"], handlerRequestID)
+			}
+			if line["grpc_method"] != info.FullMethod {
+				t.Errorf("grpc_method = %v, want %s", line["grpc_method"], info.FullMethod)
+			}
+			if line["grpc_code"] != tt.wantCode {
+				t.Errorf("grpc_code = %v, want %s", line["grpc_code"], tt.wantCode)
+			}
+			if line["level"] != tt.wantLevel {
+				t.Errorf("level = %v, want %s", line["level"], tt.wantLevel)
+			}
+		})
+	}
+}
+
+type fakeServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *fakeServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	l, buf, err := loggertest.NewLoggerWithBufferConfig(logger.LoggerConfig{})
+	if err != nil {
+		t.Fatalf("NewLoggerWithBufferConfig: %v", err)
+	}
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-request-id", "req-1"))
+
+	handler := func(srv any, ss grpc.ServerStream) error {
+		l.Info(ss.Context(), "streaming")
+		return nil
+	}
+	info := &grpc.StreamServerInfo{FullMethod: "/orders.Orders/Watch"}
+	if err := loggergrpc.StreamServerInterceptor(l)(nil, &fakeServerStream{ctx: ctx}, info, handler); err != nil {
+		t.Fatalf("interceptor: %v", err)
+	}
+
+	lines := decodeLines(t, buf)
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2:\n%s", len(lines), buf)
+	}
+	for _, line := range lines {
+		if line["request_id"] != "req-1" {
+			t.Errorf("%v: request_id = %v, want req-1", line["message"], line["request_id"])
+		}
+	}
+	if lines[1]["grpc_method"] != info.FullMethod {
+		t.Errorf("grpc_method = %v, want %s", lines[1]["grpc_method"], info.FullMethod)
+	}
+}
+
+func TestUnaryServerInterceptorStatusDetails(t *testing.T) {
+	badRequest := &errdetails.BadRequest{FieldViolations: []*errdetails.BadRequest_FieldViolation{
+		{Field: "order_id", Description: "must not be empty"},
+	}}
+	withDetails, err := status.New(codes.InvalidArgument, "invalid order").WithDetails(badRequest)
+	if err != nil {
+		t.Fatalf("WithDetails: %v", err)
+	}
+	large := status.New(codes.Internal, "boom")
+	for range 20 {
+		if large, err = large.WithDetails(&errdetails.DebugInfo{Detail: strings.Repeat("x", 500)}); err != nil {
+			t.Fatalf("WithDetails: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name        string
+		err         error
+		wantMessage any
+		wantDetails []string
+	}{
+		{"details", withDetails.Err(), "invalid order", []string{`"fieldViolations"`, `"order_id"`, `"must not be empty"`}},
+		{"no details", status.Error(codes.NotFound, "missing"), "missing", nil},
+		{"truncated", large.Err(), "boom", []string{"...[truncated]"}},
+		{"success", nil, nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, buf, err := loggertest.NewLoggerWithBufferConfig(logger.LoggerConfig{DisableStacktrace: true})
+			if err != nil {
+				t.Fatalf("NewLoggerWithBufferConfig: %v", err)
+			}
+			handler := func(ctx context.Context, req any) (any, error) { return nil, tt.err }
+			info := &grpc.UnaryServerInfo{FullMethod: "/orders.Orders/Create"}
+			loggergrpc.UnaryServerInterceptor(l)(context.Background(), "req", info, handler)
+
+			lines := decodeLines(t, buf)
+			if len(lines) != 1 {
+				t.Fatalf("got %d lines, want 1:\n%s", len(lines), buf)
+			}
+			line := lines[0]
+			if line["grpc_message"] != tt.wantMessage {
+				t.Errorf("grpc_message = %v, want %v", line["grpc_message"], tt.wantMessage)
+			}
+			details, _ := line["grpc_details"].([]any)
+			if tt.wantDetails == nil && details != nil {
+				t.Errorf("unexpected grpc_details = %v", details)
+			}
+			joined := fmt.Sprint(details...)
+			for _, want := range tt.wantDetails {
+				if !strings.Contains(joined, want) {
+					t.Errorf("grpc_details %v lack %s", details, want)
+				}
+			}
+			if size := len(joined); size > 5000 {
+				t.Errorf("grpc_details are %d bytes, want them bounded", size)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+// Package loggerprom counts log lines by level with Prometheus, so error-rate
+// spikes can be alerted on without parsing logs.
+package loggerprom
+
+import (
+	"errors"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap/zapcore"
+)
+
+// NewHook registers a log_messages_total counter labeled by level with reg
+// and returns a hook, for logger.LoggerConfig.Hooks, that increments it for
+// every entry written. If an identical counter is already registered it is
+// reused.
+func NewHook(reg prometheus.Registerer) (func(zapcore.Entry) error, error) {
+	counter := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "log_messages_total",
+		Help: "Number of log messages written, by level.",
+	}, []string{"level"})
+
+	if err := reg.Register(counter); err != nil {
+		var alreadyRegistered prometheus.AlreadyRegisteredError
+		if !errors.As(err, &alreadyRegistered) {
+			return nil, err
+		}
+		existing, ok := alreadyRegistered.ExistingCollector.(*prometheus.CounterVec)
+		if !ok {
+			return nil, err
+		}
+		counter = existing
+	}
+
+	return func(entry zapcore.Entry) error {
+		counter.WithLabelValues(entry.Level.String()).Inc()
+		return nil
+	}, nil
+}
@@ -0,0 +1,75 @@
+package loggerprom_test
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/cyrus-wg/go-logger"
+	"github.com/cyrus-wg/go-logger/loggerprom"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestNewHook(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	hook, err := loggerprom.NewHook(reg)
+	if err != nil {
+		t.Fatalf("NewHook: %v", err)
+	}
+	// A second hook on the same registry reuses the counter
+	if _, err := loggerprom.NewHook(reg); err != nil {
+		t.Fatalf("second NewHook: %v", err)
+	}
+
+	l, err := logger.NewLogger(logger.LoggerConfig{
+		Output: io.Discard,
+		Level:  logger.InfoLevel,
+		Hooks:  []func(zapcore.Entry) error{hook},
+	})
+	if err != nil {
+		t.Fatalf("NewLogger: %v", err)
+	}
+	ctx := context.Background()
+	l.Debug(ctx, "filtered")
+	l.Info(ctx, "info")
+	l.Error(ctx, "first error")
+	l.Error(ctx, "second error")
+
+	tests := []struct {
+		level string
+		want  float64
+	}{
+		{"debug", 0},
+		{"info", 1},
+		{"error", 2},
+	}
+	for _, tt := range tests {
+		if counter := counterValue(t, reg, tt.level); counter != tt.want {
+			t.Errorf("log_messages_total{level=%q} = %v, want %v", tt.level, counter, tt.want)
+		}
+	}
+}
+
+// counterValue returns the log_messages_total count for level gathered from
+// reg, zero if it was never incremented.
+func counterValue(t *testing.T, reg prometheus.Gatherer, level string) float64 {
+	t.Helper()
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather: %v", err)
+	}
+	for _, family := range families {
+		if family.GetName() != "log_messages_total" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "level" && label.GetValue() == level {
+					return metric.GetCounter().GetValue()
+				}
+			}
+		}
+	}
+	return 0
+}
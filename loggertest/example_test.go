@@ -0,0 +1,55 @@
+package loggertest_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/cyrus-wg/go-logger"
+	"github.com/cyrus-wg/go-logger/loggertest"
+)
+
+func ExampleNewTestLogger() {
+	l, logs := loggertest.NewTestLogger()
+	ctx := l.SetRequestID(context.Background(), "req-1")
+
+	l.Infow(ctx, "charge created", "amount", 42)
+	l.Errorw(ctx, "charge failed", "amount", 7)
+
+	failed := logs.FilterMessage("charge failed").FilterField("request_id", "req-1")
+	fmt.Println(logs.Len(), failed.Len(), failed.All()[0].Level)
+	// Output: 2 1 error
+}
+
+func ExampleNewLoggerWithBuffer() {
+	l, buf := loggertest.NewLoggerWithBuffer()
+	ctx := l.SetRequestID(context.Background(), "req-1")
+
+	l.Infow(ctx, "charge created", "amount", 42)
+	l.Flush()
+
+	var line map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		panic(err)
+	}
+	fmt.Println(line["level"], line["message"], line["request_id"], line["amount"])
+	// Output: INFO charge created req-1 42
+}
+
+func ExampleNewLoggerWithBufferConfig() {
+	l, buf, err := loggertest.NewLoggerWithBufferConfig(logger.LoggerConfig{
+		RedactKeys: []string{"password"},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	l.Infow(context.Background(), "login", "user", "bob", "password", "hunter2")
+
+	var line map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		panic(err)
+	}
+	fmt.Println(line["user"], line["password"])
+	// Output: bob [REDACTED]
+}
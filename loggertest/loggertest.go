@@ -0,0 +1,117 @@
+// Package loggertest provides a logger that captures entries in memory so
+// tests can assert on what was logged.
+package loggertest
+
+import (
+	"bytes"
+	"math"
+
+	"github.com/cyrus-wg/go-logger"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// ObservedLogs is a concurrency-safe collection of captured log entries.
+type ObservedLogs struct {
+	logs *observer.ObservedLogs
+}
+
+// noSampling keeps every entry, unlike the production default that drops
+// repeats of a message after the first 100 in a second.
+var noSampling = &logger.SamplingConfig{Initial: math.MaxInt, Thereafter: 1}
+
+// NewTestLogger returns a logger enabled at Debug level whose entries are
+// captured in memory instead of being written out. Sampling is off, so
+// repeated messages are all captured.
+func NewTestLogger() (*logger.Logger, *ObservedLogs) {
+	observed := &ObservedLogs{}
+	l, err := logger.NewLogger(logger.LoggerConfig{
+		Level:    logger.DebugLevel,
+		Sampling: noSampling,
+		WrapCore: func(core zapcore.Core) zapcore.Core {
+			var observerCore zapcore.Core
+			observerCore, observed.logs = observer.New(core)
+			return observerCore
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+	return l, observed
+}
+
+// NewLoggerWithBuffer returns a logger enabled at Debug level that writes
+// JSON lines into the returned buffer with the same encoder configuration as
+// a production logger, so tests can unmarshal and assert on the serialized
+// output. Sampling is off, so repeated messages are all written. Read the
+// buffer after the logging calls have returned or after Flush, not
+// concurrently with them.
+func NewLoggerWithBuffer() (*logger.Logger, *bytes.Buffer) {
+	l, buf, err := NewLoggerWithBufferConfig(logger.LoggerConfig{})
+	if err != nil {
+		panic(err)
+	}
+	return l, buf
+}
+
+// NewLoggerWithBufferConfig is NewLoggerWithBuffer for a logger built from
+// config, e.g. to test RedactKeys. Output is replaced by the buffer, and the
+// level, encoding and sampling default to Debug, "json" and off when unset.
+func NewLoggerWithBufferConfig(config logger.LoggerConfig) (*logger.Logger, *bytes.Buffer, error) {
+	buf := &bytes.Buffer{}
+	config.Output = buf
+	if config.Level == 0 {
+		config.Level = logger.DebugLevel
+	}
+	if config.Encoding == "" {
+		config.Encoding = "json"
+	}
+	if config.Sampling == nil {
+		config.Sampling = noSampling
+	}
+
+	l, err := logger.NewLogger(config)
+	if err != nil {
+		return nil, nil, err
+	}
+	return l, buf, nil
+}
+
+func (o *ObservedLogs) Len() int {
+	return o.logs.Len()
+}
+
+// All returns a copy of all captured entries, including their fields.
+func (o *ObservedLogs) All() []observer.LoggedEntry {
+	return o.logs.All()
+}
+
+// TakeAll returns all captured entries and clears the collection.
+func (o *ObservedLogs) TakeAll() []observer.LoggedEntry {
+	return o.logs.TakeAll()
+}
+
+func (o *ObservedLogs) FilterMessage(msg string) *ObservedLogs {
+	return &ObservedLogs{logs: o.logs.FilterMessage(msg)}
+}
+
+func (o *ObservedLogs) FilterMessageSnippet(snippet string) *ObservedLogs {
+	return &ObservedLogs{logs: o.logs.FilterMessageSnippet(snippet)}
+}
+
+func (o *ObservedLogs) FilterLevel(level logger.Level) *ObservedLogs {
+	return &ObservedLogs{logs: o.logs.Filter(func(e observer.LoggedEntry) bool {
+		return e.Level.String() == level.String()
+	})}
+}
+
+// FilterField keeps entries with a field named key whose value equals value,
+// compared the same way the logger encodes key-value pairs.
+func (o *ObservedLogs) FilterField(key string, value any) *ObservedLogs {
+	return &ObservedLogs{logs: o.logs.FilterField(zap.Any(key, value))}
+}
+
+func (o *ObservedLogs) FilterFieldKey(key string) *ObservedLogs {
+	return &ObservedLogs{logs: o.logs.FilterFieldKey(key)}
+}
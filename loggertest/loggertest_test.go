@@ -0,0 +1,58 @@
+package loggertest_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/cyrus-wg/go-logger"
+	"github.com/cyrus-wg/go-logger/loggertest"
+)
+
+func TestNoSampling(t *testing.T) {
+	const n = 500
+
+	l, buf := loggertest.NewLoggerWithBuffer()
+	for range n {
+		l.Info(context.Background(), "same message")
+	}
+	if got := bytes.Count(buf.Bytes(), []byte("\n")); got != n {
+		t.Errorf("NewLoggerWithBuffer wrote %d lines, want %d", got, n)
+	}
+
+	observed, logs := loggertest.NewTestLogger()
+	for range n {
+		observed.Info(context.Background(), "same message")
+	}
+	if got := logs.Len(); got != n {
+		t.Errorf("NewTestLogger captured %d entries, want %d", got, n)
+	}
+}
+
+func TestObservedLogsFilters(t *testing.T) {
+	l, logs := loggertest.NewTestLogger()
+	ctx := l.SetRequestID(context.Background(), "req-1")
+
+	l.Infow(ctx, "charge created", "amount", 42)
+	l.Warnw(ctx, "charge retried", "attempt", 2)
+	l.Errorw(context.Background(), "charge failed")
+
+	tests := []struct {
+		name string
+		logs *loggertest.ObservedLogs
+		want int
+	}{
+		{"message", logs.FilterMessage("charge created"), 1},
+		{"message snippet", logs.FilterMessageSnippet("charge"), 3},
+		{"level", logs.FilterLevel(logger.WarnLevel), 1},
+		{"field", logs.FilterField("request_id", "req-1"), 2},
+		{"field key", logs.FilterFieldKey("attempt"), 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.logs.Len(); got != tt.want {
+				t.Errorf("got %d entries, want %d", got, tt.want)
+			}
+		})
+	}
+}
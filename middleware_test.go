@@ -0,0 +1,997 @@
+package logger_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cyrus-wg/go-logger"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestMiddlewareUserIP(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    string
+	}{
+		{"X-Forwarded-For", map[string]string{"X-Forwarded-For": "203.0.113.7, 10.0.0.1"}, "203.0.113.7"},
+		{"X-Real-IP", map[string]string{"X-Real-IP": "203.0.113.8"}, "203.0.113.8"},
+		{"RemoteAddr", nil, "192.0.2.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, buf := newLogger(t, logger.LoggerConfig{})
+			handler := l.LoggerMiddleware(false, false)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if ip, _ := l.GetUserIP(r.Context()); ip != tt.want {
+					t.Errorf("GetUserIP = %q, want %q", ip, tt.want)
+				}
+				l.Info(r.Context(), "handled")
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			if line := decodeLine(t, buf); line["user_ip"] != tt.want {
+				t.Errorf("user_ip = %v, want %s", line["user_ip"], tt.want)
+			}
+		})
+	}
+}
+
+func TestMiddlewareBypassList(t *testing.T) {
+	bypass := []logger.BypassRequestLogging{
+		{Path: "/health"},
+		{Path: "/static/**"},
+		{Path: "/users/*/avatar", Methods: "GET"},
+		{Path: `/v[0-9]+/metrics`, IsRegex: true},
+	}
+	tests := []struct {
+		method string
+		path   string
+		logged bool
+	}{
+		{http.MethodGet, "/health", false},
+		{http.MethodGet, "/static/css/site.css", false},
+		{http.MethodGet, "/users/42/avatar", false},
+		{http.MethodPut, "/users/42/avatar", true},
+		{http.MethodGet, "/v2/metrics", false},
+		{http.MethodGet, "/vx/metrics", true},
+		{http.MethodGet, "/orders", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			l, buf := newLogger(t, logger.LoggerConfig{})
+			handler := l.LoggerMiddleware(true, true, bypass...)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tt.method, tt.path, nil))
+
+			want := []string{"Incoming request", "Request completed"}
+			if !tt.logged {
+				want = nil
+			}
+			if got := messages(t, buf); !slices.Equal(got, want) {
+				t.Errorf("logged %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestMiddlewareStatus(t *testing.T) {
+	tests := []struct {
+		name      string
+		handler   http.HandlerFunc
+		wantCode  float64
+		wantBytes float64
+	}{
+		{"404", func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "not found", http.StatusNotFound)
+		}, 404, 10},
+		{"implicit 200", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("ok"))
+		}, 200, 2},
+		{"after informational", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusEarlyHints)
+			w.WriteHeader(http.StatusCreated)
+		}, 201, 0},
+		{"second WriteHeader ignored", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusAccepted)
+			w.WriteHeader(http.StatusInternalServerError)
+		}, 202, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, buf := newLogger(t, logger.LoggerConfig{})
+			l.LoggerMiddleware(false, true)(tt.handler).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+			line := decodeLine(t, buf)
+			if line["status"] != tt.wantCode {
+				t.Errorf("status = %v, want %v", line["status"], tt.wantCode)
+			}
+			if line["bytes_written"] != tt.wantBytes {
+				t.Errorf("bytes_written = %v, want %v", line["bytes_written"], tt.wantBytes)
+			}
+		})
+	}
+}
+
+func TestMiddlewareFlushAndHijack(t *testing.T) {
+	l, _ := newLogger(t, logger.LoggerConfig{})
+	server := httptest.NewServer(l.LoggerMiddleware(false, true)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/flush" {
+			w.Write([]byte("chunk"))
+			w.(http.Flusher).Flush()
+			return
+		}
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Errorf("Hijack: %v", err)
+			return
+		}
+		defer conn.Close()
+		rw.WriteString("HTTP/1.1 200 OK\r\nContent-Length: 8\r\nConnection: close\r\n\r\nhijacked")
+		rw.Flush()
+	})))
+	defer server.Close()
+
+	for path, want := range map[string]string{"/flush": "chunk", "/hijack": "hijacked"} {
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(body) != want {
+			t.Errorf("GET %s body = %q, want %q", path, body, want)
+		}
+	}
+}
+
+func TestMiddlewareCompletionLevel(t *testing.T) {
+	tests := []struct {
+		status  int
+		uniform bool
+		want    string
+	}{
+		{http.StatusOK, false, "INFO"},
+		{http.StatusFound, false, "INFO"},
+		{http.StatusBadRequest, false, "WARN"},
+		{http.StatusNotFound, false, "WARN"},
+		{http.StatusInternalServerError, false, "ERROR"},
+		{http.StatusServiceUnavailable, false, "ERROR"},
+		{http.StatusInternalServerError, true, "INFO"},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d uniform=%t", tt.status, tt.uniform), func(t *testing.T) {
+			l, buf := newLogger(t, logger.LoggerConfig{DisableStacktrace: true})
+			middleware := l.LoggerMiddlewareWithConfig(logger.MiddlewareConfig{
+				LogCompleteTime:        true,
+				UniformCompletionLevel: tt.uniform,
+			})
+			middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+			})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+			if line := decodeLine(t, buf); line["level"] != tt.want {
+				t.Errorf("level = %v, want %s", line["level"], tt.want)
+			}
+		})
+	}
+}
+
+func TestMiddlewareRequestIDHeaders(t *testing.T) {
+	tests := []struct {
+		name       string
+		headers    []string
+		request    map[string]string
+		reuse      string
+		echoHeader string
+	}{
+		{"reuses X-Request-ID", nil, map[string]string{"X-Request-ID": "req-1"}, "req-1", "X-Request-ID"},
+		{"generates without header", nil, nil, "", "X-Request-ID"},
+		{"configured headers in order", []string{"X-Correlation-ID", "X-Request-ID"}, map[string]string{"X-Request-ID": "req-2"}, "req-2", "X-Correlation-ID"},
+		{"first configured header wins", []string{"X-Correlation-ID", "X-Request-ID"}, map[string]string{"X-Request-ID": "req-2", "X-Correlation-ID": "corr-1"}, "corr-1", "X-Correlation-ID"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, buf := newLogger(t, logger.LoggerConfig{})
+			var handlerID string
+			handler := l.LoggerMiddlewareWithConfig(logger.MiddlewareConfig{RequestIDHeaders: tt.headers})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				handlerID, _ = l.GetRequestID(r.Context())
+				l.Info(r.Context(), "handled")
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			for k, v := range tt.request {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if tt.reuse != "" && handlerID != tt.reuse {
+				t.Errorf("request ID = %q, want %q", handlerID, tt.reuse)
+			}
+			if tt.reuse == "" && handlerID == "" {
+				t.Error("no request ID was generated")
+			}
+			if got := rec.Header().Get(tt.echoHeader); got != handlerID {
+				t.Errorf("%s response header = %q, want %q", tt.echoHeader, got, handlerID)
+			}
+			if line := decodeLine(t, buf); line["request_id"] != handlerID {
+				t.Errorf("request_id = %v, want %q", line["request_id"], handlerID)
+			}
+		})
+	}
+}
+
+func TestMiddlewareRecoverPanics(t *testing.T) {
+	l, buf := newLogger(t, logger.LoggerConfig{DisableStacktrace: true})
+	handler := l.LoggerMiddlewareWithConfig(logger.MiddlewareConfig{
+		LogCompleteTime: true,
+		RecoverPanics:   true,
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("nil map")
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Request-ID", "req-1")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("response status = %d, want 500", rec.Code)
+	}
+	lines := decodeLines(t, buf)
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2:\n%s", len(lines), buf)
+	}
+	recovered, completed := lines[0], lines[1]
+	if recovered["message"] != "Recovered from panic" || recovered["level"] != "ERROR" {
+		t.Errorf("first line = %v %v, want ERROR Recovered from panic", recovered["level"], recovered["message"])
+	}
+	if recovered["panic"] != "nil map" || recovered["request_id"] != "req-1" {
+		t.Errorf("panic = %v, request_id = %v, want nil map, req-1", recovered["panic"], recovered["request_id"])
+	}
+	if stack, _ := recovered["stack"].(string); !strings.Contains(stack, "TestMiddlewareRecoverPanics") {
+		t.Errorf("stack does not include the handler:\n%s", stack)
+	}
+	if completed["message"] != "Request completed" || completed["status"] != float64(500) {
+		t.Errorf("completion = %v status %v, want Request completed status 500", completed["message"], completed["status"])
+	}
+}
+
+func TestMiddlewarePanicsWithoutRecovery(t *testing.T) {
+	tests := []struct {
+		name   string
+		config logger.MiddlewareConfig
+		value  any
+	}{
+		{"recovery disabled", logger.MiddlewareConfig{}, "nil map"},
+		{"ErrAbortHandler", logger.MiddlewareConfig{RecoverPanics: true}, http.ErrAbortHandler},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, _ := newLogger(t, logger.LoggerConfig{})
+			handler := l.LoggerMiddlewareWithConfig(tt.config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				panic(tt.value)
+			}))
+			defer func() {
+				if r := recover(); r != tt.value {
+					t.Errorf("recovered %v, want the handler's panic %v", r, tt.value)
+				}
+			}()
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		})
+	}
+}
+
+func TestMiddlewareGoexitIsNotAPanic(t *testing.T) {
+	l, buf := newLogger(t, logger.LoggerConfig{DisableStacktrace: true})
+	handler := l.LoggerMiddlewareWithConfig(logger.MiddlewareConfig{
+		LogCompleteTime: true,
+		RecoverPanics:   true,
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		runtime.Goexit()
+	}))
+
+	rec := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	}()
+	<-done
+
+	if rec.Code == http.StatusInternalServerError {
+		t.Errorf("response status = %d, want no 500 after runtime.Goexit", rec.Code)
+	}
+	for _, line := range decodeLines(t, buf) {
+		if line["message"] == "Recovered from panic" {
+			t.Errorf("runtime.Goexit was logged as a panic: %v", line)
+		}
+	}
+}
+
+func TestMiddlewareSlowRequest(t *testing.T) {
+	tests := []struct {
+		name      string
+		sleep     time.Duration
+		status    int
+		wantLevel string
+		wantSlow  any
+	}{
+		{"fast", 0, http.StatusOK, "INFO", nil},
+		{"slow", 30 * time.Millisecond, http.StatusOK, "WARN", true},
+		{"slow server error", 30 * time.Millisecond, http.StatusInternalServerError, "ERROR", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, buf := newLogger(t, logger.LoggerConfig{DisableStacktrace: true})
+			handler := l.LoggerMiddlewareWithConfig(logger.MiddlewareConfig{
+				LogCompleteTime:      true,
+				SlowRequestThreshold: 20 * time.Millisecond,
+			})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				time.Sleep(tt.sleep)
+				w.WriteHeader(tt.status)
+			}))
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+			line := decodeLine(t, buf)
+			if line["level"] != tt.wantLevel {
+				t.Errorf("level = %v, want %s", line["level"], tt.wantLevel)
+			}
+			if line["slow"] != tt.wantSlow {
+				t.Errorf("slow = %v, want %v", line["slow"], tt.wantSlow)
+			}
+			latency, err := time.ParseDuration(line["latency"].(string))
+			if err != nil {
+				t.Fatalf("latency %v: %v", line["latency"], err)
+			}
+			if latencyMS, _ := line["latency_ms"].(float64); latencyMS < float64(tt.sleep.Milliseconds()) || latency < tt.sleep {
+				t.Errorf("latency = %v, latency_ms = %v, want at least %v", latency, latencyMS, tt.sleep)
+			}
+		})
+	}
+}
+
+func TestMiddlewareFilter(t *testing.T) {
+	isProbe := func(r *http.Request) bool {
+		return strings.HasPrefix(r.UserAgent(), "kube-probe/")
+	}
+	tests := []struct {
+		name      string
+		userAgent string
+		path      string
+		logged    bool
+	}{
+		{"probe", "kube-probe/1.29", "/ready", false},
+		{"browser", "Mozilla/5.0", "/ready", true},
+		{"bypass list still applies", "Mozilla/5.0", "/health", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, buf := newLogger(t, logger.LoggerConfig{})
+			handled := false
+			handler := l.LoggerMiddlewareWithFilter(isProbe, true, true, logger.BypassRequestLogging{Path: "/health"})(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+				handled = true
+			}))
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set("User-Agent", tt.userAgent)
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			if !handled {
+				t.Error("filtered request was not handled")
+			}
+			if got := len(decodeLines(t, buf)) > 0; got != tt.logged {
+				t.Errorf("logged = %t, want %t:\n%s", got, tt.logged, buf)
+			}
+		})
+	}
+}
+
+func TestMiddlewareSingleLine(t *testing.T) {
+	l, buf := newLogger(t, logger.LoggerConfig{})
+	handler := l.LoggerMiddlewareWithConfig(logger.MiddlewareConfig{
+		LogRequestDetails: true,
+		LogMode:           logger.SingleLine,
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+	req := httptest.NewRequest(http.MethodPost, "/orders?id=1", nil)
+	req.Header.Set("X-Forwarded-For", "203.0.113.7")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	line := decodeLine(t, buf)
+	want := map[string]any{
+		"message": "Request completed",
+		"method":  "POST",
+		"path":    "/orders",
+		"status":  float64(201),
+		"user_ip": "203.0.113.7",
+	}
+	for k, v := range want {
+		if line[k] != v {
+			t.Errorf("%s = %v, want %v", k, line[k], v)
+		}
+	}
+	for _, k := range []string{"latency", "latency_ms", "request_id", "details"} {
+		if _, ok := line[k]; !ok {
+			t.Errorf("missing %s", k)
+		}
+	}
+}
+
+func TestMiddlewareHeaderAllowList(t *testing.T) {
+	tests := []struct {
+		name          string
+		allowList     []string
+		wantHeaders   map[string]any
+		absentHeaders []string
+	}{
+		{
+			name:          "default",
+			wantHeaders:   map[string]any{"user_agent": "curl/8.5", "x_forwarded_for": "203.0.113.7"},
+			absentHeaders: []string{"authorization", "cookie", "x_tenant"},
+		},
+		{
+			name:          "allow list",
+			allowList:     []string{"X-Tenant", "Accept"},
+			wantHeaders:   map[string]any{"x_tenant": "acme, globex", "accept": ""},
+			absentHeaders: []string{"authorization", "cookie", "user_agent", "x_forwarded_for"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, buf := newLogger(t, logger.LoggerConfig{})
+			handler := l.LoggerMiddlewareWithConfig(logger.MiddlewareConfig{
+				LogRequestDetails:       true,
+				LogMode:                 logger.SingleLine,
+				HeaderAllowList:         tt.allowList,
+				ResponseHeaderAllowList: []string{"Cache-Control"},
+			})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Cache-Control", "no-store")
+				w.Header().Set("Set-Cookie", "session=secret")
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("User-Agent", "curl/8.5")
+			req.Header.Set("X-Forwarded-For", "203.0.113.7")
+			req.Header.Set("Authorization", "Bearer secret")
+			req.Header.Set("Cookie", "session=secret")
+			req.Header.Add("X-Tenant", "acme")
+			req.Header.Add("X-Tenant", "globex")
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			line := decodeLine(t, buf)
+			details, _ := line["details"].(map[string]any)
+			for k, v := range tt.wantHeaders {
+				if details[k] != v {
+					t.Errorf("details.%s = %v, want %v", k, details[k], v)
+				}
+			}
+			for _, k := range tt.absentHeaders {
+				if _, ok := details[k]; ok {
+					t.Errorf("unexpected details.%s = %v", k, details[k])
+				}
+			}
+			want := map[string]any{"cache_control": "no-store"}
+			if got, _ := line["response_headers"].(map[string]any); !maps.Equal(got, want) {
+				t.Errorf("response_headers = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestMiddlewareRequestIDGenerator(t *testing.T) {
+	tests := []struct {
+		name   string
+		prefix string
+		want   []string
+	}{
+		{"generator", "", []string{"id-1", "id-2"}},
+		{"generator with prefix", "api-", []string{"api-id-1", "api-id-2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := 0
+			l, buf := newLogger(t, logger.LoggerConfig{
+				RequestIDPrefix: tt.prefix,
+				RequestIDGenerator: func() string {
+					n++
+					return fmt.Sprintf("id-%d", n)
+				},
+			})
+			handler := l.LoggerMiddleware(false, true)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+			var headers []string
+			for range tt.want {
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+				headers = append(headers, rec.Header().Get("X-Request-ID"))
+			}
+
+			var logged []string
+			for _, line := range decodeLines(t, buf) {
+				id, _ := line["request_id"].(string)
+				logged = append(logged, id)
+			}
+			if !slices.Equal(logged, tt.want) {
+				t.Errorf("logged request IDs %v, want %v", logged, tt.want)
+			}
+			if !slices.Equal(headers, tt.want) {
+				t.Errorf("X-Request-ID headers %v, want %v", headers, tt.want)
+			}
+		})
+	}
+}
+
+func TestMiddlewareRequestTimes(t *testing.T) {
+	l, buf := newLogger(t, logger.LoggerConfig{})
+	handler := l.LoggerMiddleware(false, true)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		time.Sleep(5 * time.Millisecond)
+	}))
+	before := time.Now()
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	after := time.Now()
+
+	line := decodeLine(t, buf)
+	details, _ := line["details"].(map[string]any)
+	times := make(map[string]time.Time)
+	for _, key := range []string{"request_start", "request_end"} {
+		value, _ := details[key].(string)
+		parsed, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			t.Fatalf("%s = %q is not RFC 3339: %v", key, value, err)
+		}
+		if parsed.Before(before.Truncate(time.Microsecond)) || parsed.After(after) {
+			t.Errorf("%s = %v, want between %v and %v", key, parsed, before, after)
+		}
+		times[key] = parsed
+		if _, ok := line[key]; ok {
+			t.Errorf("%s is logged at the top level, want it in details", key)
+		}
+	}
+
+	latency, err := time.ParseDuration(line["latency"].(string))
+	if err != nil {
+		t.Fatalf("latency %v: %v", line["latency"], err)
+	}
+	if got := times["request_end"].Sub(times["request_start"]); got.Round(time.Microsecond) != latency.Round(time.Microsecond) {
+		t.Errorf("request_end - request_start = %v, want latency %v", got, latency)
+	}
+}
+
+func TestMiddlewareClientDisconnect(t *testing.T) {
+	tests := []struct {
+		name      string
+		tag       bool
+		cancel    bool
+		wantLevel string
+		want      any
+	}{
+		{"tagged", true, true, "INFO", true},
+		{"not canceled", true, false, "ERROR", nil},
+		{"tagging disabled", false, true, "ERROR", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, buf := newLogger(t, logger.LoggerConfig{DisableStacktrace: true})
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			handler := l.LoggerMiddlewareWithConfig(logger.MiddlewareConfig{
+				LogCompleteTime:      true,
+				TagClientDisconnects: tt.tag,
+			})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.cancel {
+					cancel()
+				}
+				w.WriteHeader(http.StatusInternalServerError)
+			}))
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequestWithContext(ctx, http.MethodGet, "/", nil))
+
+			line := decodeLine(t, buf)
+			if line["level"] != tt.wantLevel {
+				t.Errorf("level = %v, want %s", line["level"], tt.wantLevel)
+			}
+			if line["client_disconnected"] != tt.want {
+				t.Errorf("client_disconnected = %v, want %v", line["client_disconnected"], tt.want)
+			}
+		})
+	}
+}
+
+func TestMiddlewareLoggerFromContext(t *testing.T) {
+	l, buf := newLogger(t, logger.LoggerConfig{})
+	handler := l.LoggerMiddleware(false, false)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		stored, ok := logger.LoggerFromContext(r.Context())
+		if !ok || stored != l {
+			t.Errorf("LoggerFromContext = %p, %t, want the middleware logger %p", stored, ok, l)
+		}
+		logger.FromContext(r.Context()).WithFields("component", "orders").Infow(r.Context(), "handled", "order", 7)
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	line := decodeLine(t, buf)
+	want := map[string]any{"message": "handled", "component": "orders", "order": float64(7)}
+	for k, v := range want {
+		if line[k] != v {
+			t.Errorf("%s = %v, want %v", k, line[k], v)
+		}
+	}
+	if id, _ := line["request_id"].(string); id == "" {
+		t.Error("entry logged through FromContext has no request_id")
+	}
+
+	if _, ok := logger.LoggerFromContext(context.Background()); ok {
+		t.Error("LoggerFromContext found a logger in an empty context")
+	}
+}
+
+func TestMiddlewareLatencyMS(t *testing.T) {
+	const sleep = 100 * time.Millisecond
+	tests := []struct {
+		name      string
+		threshold time.Duration
+		wantLevel string
+	}{
+		{"completion", 0, "INFO"},
+		{"slow request", sleep / 2, "WARN"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, buf := newLogger(t, logger.LoggerConfig{})
+			handler := l.LoggerMiddlewareWithConfig(logger.MiddlewareConfig{
+				LogCompleteTime:      true,
+				SlowRequestThreshold: tt.threshold,
+			})(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+				time.Sleep(sleep)
+			}))
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+			line := decodeLine(t, buf)
+			if line["level"] != tt.wantLevel {
+				t.Errorf("level = %v, want %s", line["level"], tt.wantLevel)
+			}
+			latencyMS, ok := line["latency_ms"].(float64)
+			if !ok {
+				t.Fatalf("latency_ms = %v, want a number", line["latency_ms"])
+			}
+			if want := float64(sleep.Milliseconds()); latencyMS < want || latencyMS > 2*want {
+				t.Errorf("latency_ms = %v, want about %v", latencyMS, want)
+			}
+			latency, err := time.ParseDuration(line["latency"].(string))
+			if err != nil {
+				t.Fatalf("latency %v: %v", line["latency"], err)
+			}
+			if got := float64(latency) / float64(time.Millisecond); got != latencyMS {
+				t.Errorf("latency = %v does not match latency_ms = %v", latency, latencyMS)
+			}
+		})
+	}
+}
+
+func TestMiddlewareLatencyObserver(t *testing.T) {
+	spanContext := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+		SpanID:  trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+	})
+	tests := []struct {
+		name        string
+		path        string
+		ctx         context.Context
+		wantTraceID string
+	}{
+		{"without span", "/orders", context.Background(), ""},
+		{"with span", "/orders", trace.ContextWithSpanContext(context.Background(), spanContext), "4bf92f3577b34da6a3ce929d0e0e4736"},
+		{"bypassed path", "/health", context.Background(), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int
+			var gotPath, gotTraceID string
+			var gotSeconds float64
+			l, _ := newLogger(t, logger.LoggerConfig{
+				LatencyObserver: func(path string, seconds float64, traceID string) {
+					calls++
+					gotPath, gotSeconds, gotTraceID = path, seconds, traceID
+				},
+			})
+			handler := l.LoggerMiddleware(false, true, logger.BypassRequestLogging{Path: "/health"})(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+				time.Sleep(time.Millisecond)
+			}))
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequestWithContext(tt.ctx, http.MethodGet, tt.path, nil))
+
+			if calls != 1 {
+				t.Fatalf("observer called %d times, want 1", calls)
+			}
+			if gotPath != tt.path {
+				t.Errorf("path = %q, want %q", gotPath, tt.path)
+			}
+			if gotSeconds < 0.001 {
+				t.Errorf("seconds = %v, want at least 0.001", gotSeconds)
+			}
+			if gotTraceID != tt.wantTraceID {
+				t.Errorf("traceID = %q, want %q", gotTraceID, tt.wantTraceID)
+			}
+		})
+	}
+}
+
+func TestMiddlewareOmitEmptyDetails(t *testing.T) {
+	tests := []struct {
+		name   string
+		omit   bool
+		absent []string
+		want   map[string]any
+	}{
+		{"stable schema", false, nil, map[string]any{"referer": "", "origin": "", "x_client_ip": "", "content_length": float64(0), "user_agent": "curl/8.5"}},
+		{"omit empty", true, []string{"referer", "origin", "x_client_ip", "content_length", "query_params"}, map[string]any{"user_agent": "curl/8.5", "method": "GET"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, buf := newLogger(t, logger.LoggerConfig{})
+			handler := l.LoggerMiddlewareWithConfig(logger.MiddlewareConfig{
+				LogRequestDetails: true,
+				OmitEmptyDetails:  tt.omit,
+			})(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+			req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+			req.Header.Set("User-Agent", "curl/8.5")
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			details, _ := decodeLine(t, buf)["details"].(map[string]any)
+			for k, v := range tt.want {
+				if got, ok := details[k]; !ok || got != v {
+					t.Errorf("details.%s = %v, want %v", k, got, v)
+				}
+			}
+			for _, k := range tt.absent {
+				if _, ok := details[k]; ok {
+					t.Errorf("unexpected details.%s = %v", k, details[k])
+				}
+			}
+		})
+	}
+}
+
+func TestMiddlewareRedactQueryParams(t *testing.T) {
+	tests := []struct {
+		name      string
+		redact    []string
+		target    string
+		wantURL   string
+		wantQuery string
+	}{
+		{"token", []string{"token"}, "/callback?token=s3cret&page=2", "/callback?page=2&token=REDACTED", "page=2&token=REDACTED"},
+		{"case-insensitive and repeated", []string{"token"}, "/callback?Token=a&Token=b", "/callback?Token=REDACTED&Token=REDACTED", "Token=REDACTED&Token=REDACTED"},
+		{"nothing to redact", []string{"token"}, "/callback?z=1&a=2", "/callback?z=1&a=2", "z=1&a=2"},
+		{"not configured", nil, "/callback?token=s3cret", "/callback?token=s3cret", "token=s3cret"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, buf := newLogger(t, logger.LoggerConfig{})
+			handler := l.LoggerMiddlewareWithConfig(logger.MiddlewareConfig{
+				LogRequestDetails: true,
+				RedactQueryParams: tt.redact,
+			})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.RawQuery == "" || strings.Contains(r.URL.RawQuery, "REDACTED") {
+					t.Errorf("handler got query %q, want the original", r.URL.RawQuery)
+				}
+			}))
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tt.target, nil))
+
+			details, _ := decodeLine(t, buf)["details"].(map[string]any)
+			if details["url"] != tt.wantURL {
+				t.Errorf("url = %v, want %s", details["url"], tt.wantURL)
+			}
+			if details["query_params"] != tt.wantQuery {
+				t.Errorf("query_params = %v, want %s", details["query_params"], tt.wantQuery)
+			}
+		})
+	}
+}
+
+func TestMiddlewareRequestIDValidation(t *testing.T) {
+	huge := strings.Repeat("a", 10*1024)
+	tests := []struct {
+		name      string
+		validator func(string) bool
+		inbound   string
+		reused    bool
+	}{
+		{"valid", nil, "req-1", true},
+		{"10KB", nil, huge, false},
+		{"header injection", nil, "req-1\r\nX-Admin: true", false},
+		{"custom validator rejects", func(id string) bool { return strings.HasPrefix(id, "svc-") }, "req-1", false},
+		{"custom validator accepts", func(id string) bool { return strings.HasPrefix(id, "svc-") }, "svc-1", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, buf := newLogger(t, logger.LoggerConfig{RequestIDValidator: tt.validator})
+			var handlerID string
+			handler := l.LoggerMiddleware(false, false)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				handlerID, _ = l.GetRequestID(r.Context())
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header["X-Request-Id"] = []string{tt.inbound}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if got := handlerID == tt.inbound; got != tt.reused {
+				t.Errorf("request ID %.20q reused = %t, want %t", handlerID, got, tt.reused)
+			}
+			if handlerID == "" || rec.Header().Get("X-Request-ID") != handlerID {
+				t.Errorf("X-Request-ID response header = %.20q, want the request ID %.20q", rec.Header().Get("X-Request-ID"), handlerID)
+			}
+
+			var want []string
+			if !tt.reused {
+				want = []string{"Rejected invalid inbound request ID"}
+			}
+			if got := messages(t, buf); !slices.Equal(got, want) {
+				t.Errorf("logged %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestMiddlewareLogTLS(t *testing.T) {
+	tests := []struct {
+		name    string
+		logTLS  bool
+		useTLS  bool
+		wantTLS bool
+	}{
+		{"https", true, true, true},
+		{"plain http", true, false, false},
+		{"disabled", false, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, buf := newLogger(t, logger.LoggerConfig{})
+			handler := l.LoggerMiddlewareWithConfig(logger.MiddlewareConfig{
+				LogRequestDetails: true,
+				LogTLS:            tt.logTLS,
+			})(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+			newServer := httptest.NewServer
+			if tt.useTLS {
+				newServer = httptest.NewTLSServer
+			}
+			server := newServer(handler)
+			defer server.Close()
+			client := server.Client()
+			if tt.useTLS {
+				// The test certificate is valid for example.com, which the
+				// client then sends as SNI
+				client.Transport.(*http.Transport).TLSClientConfig.ServerName = "example.com"
+			}
+			resp, err := client.Get(server.URL)
+			if err != nil {
+				t.Fatalf("Get: %v", err)
+			}
+			resp.Body.Close()
+
+			details, _ := decodeLine(t, buf)["details"].(map[string]any)
+			for _, k := range []string{"tls_version", "tls_cipher", "tls_sni"} {
+				_, ok := details[k]
+				if ok != tt.wantTLS {
+					t.Errorf("has details.%s = %t, want %t", k, ok, tt.wantTLS)
+				}
+			}
+			if tt.wantTLS {
+				if v, _ := details["tls_version"].(string); !strings.HasPrefix(v, "TLS 1.") {
+					t.Errorf("tls_version = %q, want a TLS version name", v)
+				}
+				if v, _ := details["tls_cipher"].(string); !strings.HasPrefix(v, "TLS_") {
+					t.Errorf("tls_cipher = %q, want a cipher suite name", v)
+				}
+				if details["tls_sni"] != "example.com" {
+					t.Errorf("tls_sni = %v, want example.com", details["tls_sni"])
+				}
+			}
+		})
+	}
+}
+
+func TestMiddlewareRequestDetailGroups(t *testing.T) {
+	tests := []struct {
+		name   string
+		groups logger.RequestDetailGroup
+		want   []string
+		absent []string
+	}{
+		{
+			name:   "basic only",
+			groups: logger.BasicInfo,
+			want:   []string{"method", "url", "path", "query_params", "protocol", "host"},
+			absent: []string{"user_ip", "remote_addr", "user_agent", "content_length", "origin", "x_forwarded_for", "x_real_ip"},
+		},
+		{
+			name:   "client and proxy",
+			groups: logger.ClientInfo | logger.ProxyHeaders,
+			want:   []string{"user_ip", "remote_addr", "user_agent", "x_forwarded_for", "x_real_ip"},
+			absent: []string{"method", "url", "content_length", "origin"},
+		},
+		{
+			name: "default",
+			want: []string{"method", "user_ip", "user_agent", "content_length", "origin", "x_forwarded_for"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, buf := newLogger(t, logger.LoggerConfig{})
+			handler := l.LoggerMiddlewareWithConfig(logger.MiddlewareConfig{
+				LogRequestDetails:   true,
+				RequestDetailGroups: tt.groups,
+			})(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+			req := httptest.NewRequest(http.MethodGet, "/orders?page=2", nil)
+			req.Header.Set("User-Agent", "curl/8.5")
+			req.Header.Set("Origin", "https://example.com")
+			req.Header.Set("X-Forwarded-For", "203.0.113.7")
+			req.Header.Set("X-Real-IP", "203.0.113.7")
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			details, _ := decodeLine(t, buf)["details"].(map[string]any)
+			for _, k := range tt.want {
+				if _, ok := details[k]; !ok {
+					t.Errorf("missing details.%s", k)
+				}
+			}
+			for _, k := range tt.absent {
+				if _, ok := details[k]; ok {
+					t.Errorf("unexpected details.%s = %v", k, details[k])
+				}
+			}
+		})
+	}
+}
+
+func TestMiddlewarePanicResponse(t *testing.T) {
+	tests := []struct {
+		name            string
+		response        func(w http.ResponseWriter, requestID string)
+		writeFirst      bool
+		wantStatus      int
+		wantContentType string
+		wantBody        string
+	}{
+		{"default", nil, false, http.StatusInternalServerError, "text/plain; charset=utf-8", "Internal Server Error\n"},
+		{"JSON", logger.JSONPanicResponse, false, http.StatusInternalServerError, "application/json", `{"error":"internal","request_id":"req-1"}` + "\n"},
+		{"after partial write", logger.JSONPanicResponse, true, http.StatusAccepted, "", "partial"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, _ := newLogger(t, logger.LoggerConfig{DisableStacktrace: true})
+			handler := l.LoggerMiddlewareWithConfig(logger.MiddlewareConfig{
+				RecoverPanics: true,
+				PanicResponse: tt.response,
+			})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.writeFirst {
+					w.WriteHeader(http.StatusAccepted)
+					io.WriteString(w, "partial")
+				}
+				panic("nil map")
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("X-Request-ID", "req-1")
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := rec.Header().Get("Content-Type"); tt.wantContentType != "" && got != tt.wantContentType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.wantContentType)
+			}
+			if got := rec.Body.String(); got != tt.wantBody {
+				t.Errorf("body = %q, want %q", got, tt.wantBody)
+			}
+		})
+	}
+}
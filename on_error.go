@@ -0,0 +1,53 @@
+package logger
+
+import (
+	"context"
+	"os"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// notifyError passes an Error, Panic or Fatal entry to the OnError callback.
+// A panicking callback must not take the caller down with it.
+func (l *Logger) notifyError(ctx context.Context, msg string, keysAndValues []any) {
+	defer func() { _ = recover() }()
+	l.onError(ctx, msg, attributesToMap(keysAndValues))
+}
+
+// attributesToMap converts a sugared key-value slice, which may contain
+// zap.Field entries, into a map. Later keys overwrite earlier ones, and keys
+// after a zap.Namespace are nested under it as the encoder writes them.
+func attributesToMap(keysAndValues []any) map[string]any {
+	fields := make(map[string]any, len(keysAndValues)/2)
+	current := fields
+	for i := 0; i < len(keysAndValues); {
+		if field, ok := keysAndValues[i].(zapcore.Field); ok {
+			if field.Type == zapcore.NamespaceType {
+				namespace := make(map[string]any)
+				current[field.Key] = namespace
+				current = namespace
+			} else {
+				current[field.Key] = fieldValue(field)
+			}
+			i++
+			continue
+		}
+
+		if i+1 >= len(keysAndValues) {
+			break
+		}
+		if key, ok := keysAndValues[i].(string); ok {
+			current[key] = keysAndValues[i+1]
+		}
+		i += 2
+	}
+	return fields
+}
+
+// exitAfter is a fatal hook that runs fn before exiting like zap's default.
+type exitAfter func()
+
+func (fn exitAfter) OnWrite(*zapcore.CheckedEntry, []zapcore.Field) {
+	fn()
+	os.Exit(1)
+}
@@ -0,0 +1,108 @@
+package logger_test
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/cyrus-wg/go-logger"
+	"go.uber.org/zap"
+)
+
+type errorCall struct {
+	msg         string
+	fields      map[string]any
+	linesAtCall int
+}
+
+func TestOnError(t *testing.T) {
+	tests := []struct {
+		name  string
+		log   func(l *logger.Logger, ctx context.Context)
+		calls int
+	}{
+		{"Info", func(l *logger.Logger, ctx context.Context) { l.Infow(ctx, "fine", "k", "v") }, 0},
+		{"Warn", func(l *logger.Logger, ctx context.Context) { l.Warnw(ctx, "careful", "k", "v") }, 0},
+		{"Error", func(l *logger.Logger, ctx context.Context) { l.Errorw(ctx, "failed", "k", "v") }, 1},
+		{"Check", func(l *logger.Logger, ctx context.Context) {
+			l.Check(ctx, logger.ErrorLevel, "failed").Write()
+		}, 1},
+		{"Panic", func(l *logger.Logger, ctx context.Context) {
+			defer func() { _ = recover() }()
+			l.Panicw(ctx, "failed", "k", "v")
+		}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []errorCall
+			var buf *bytes.Buffer
+			l, buf := newLogger(t, logger.LoggerConfig{
+				DisableStacktrace: true,
+				OnError: func(ctx context.Context, msg string, fields map[string]any) {
+					calls = append(calls, errorCall{msg, fields, len(decodeLines(t, buf))})
+				},
+			})
+			ctx := l.SetRequestID(context.Background(), "req-1")
+			tt.log(l, ctx)
+
+			if len(calls) != tt.calls {
+				t.Fatalf("OnError called %d times, want %d", len(calls), tt.calls)
+			}
+			for _, call := range calls {
+				if call.msg != "failed" || call.fields["request_id"] != "req-1" {
+					t.Errorf("OnError(%q, %v), want failed with request_id req-1", call.msg, call.fields)
+				}
+				if call.linesAtCall != 1 {
+					t.Errorf("OnError ran before the entry was written")
+				}
+			}
+		})
+	}
+}
+
+func TestOnErrorPanicIsRecovered(t *testing.T) {
+	l, buf := newLogger(t, logger.LoggerConfig{
+		DisableStacktrace: true,
+		OnError:           func(context.Context, string, map[string]any) { panic("tracker down") },
+	})
+	l.Error(context.Background(), "failed")
+	l.Info(context.Background(), "still logging")
+
+	if got := len(decodeLines(t, buf)); got != 2 {
+		t.Errorf("got %d lines, want 2", got)
+	}
+}
+
+func TestOnErrorWithGroup(t *testing.T) {
+	var calls []map[string]any
+	l, buf := newLogger(t, logger.LoggerConfig{
+		DisableStacktrace: true,
+		OnError: func(_ context.Context, _ string, fields map[string]any) {
+			calls = append(calls, fields)
+		},
+	})
+	ctx := l.SetRequestID(context.Background(), "req-1")
+	g := l.WithFields("component", "api").WithGroup("http").WithFields("method", "GET").WithGroup("response")
+	g.Errorw(ctx, "failed", "status", 500)
+	g.LogFields(ctx, logger.ErrorLevel, "failed", zap.Int("status", 500))
+
+	lines := decodeLines(t, buf)
+	if len(calls) != 2 || len(lines) != 2 {
+		t.Fatalf("got %d OnError calls and %d lines, want 2 each", len(calls), len(lines))
+	}
+	for i, status := range []any{500, int64(500)} {
+		want := map[string]any{
+			"request_id": "req-1",
+			"component":  "api",
+			"http":       map[string]any{"method": "GET", "response": map[string]any{"status": status}},
+		}
+		if !reflect.DeepEqual(calls[i], want) {
+			t.Errorf("OnError fields = %v, want %v", calls[i], want)
+		}
+		logged := map[string]any{"method": "GET", "response": map[string]any{"status": float64(500)}}
+		if !reflect.DeepEqual(lines[i]["http"], logged) {
+			t.Errorf("logged http = %v, want %v", lines[i]["http"], logged)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package logger
+
+import (
+	"net/url"
+	"strings"
+)
+
+const redactedQueryValue = "REDACTED"
+
+// redactQuery returns the URL and raw query of u as logged, with the values of
+// the params in redactParams, matched case-insensitively, replaced by
+// "REDACTED". The query is only re-encoded, which sorts it by key, when a
+// param is redacted.
+func redactQuery(u *url.URL, redactParams map[string]struct{}) (string, string) {
+	if len(redactParams) == 0 || u.RawQuery == "" {
+		return u.String(), u.RawQuery
+	}
+
+	// Malformed pairs are dropped by ParseQuery, so they can't leak either
+	query, _ := url.ParseQuery(u.RawQuery)
+	redacted := false
+	for key, values := range query {
+		if _, ok := redactParams[strings.ToLower(key)]; ok {
+			for i := range values {
+				values[i] = redactedQueryValue
+			}
+			redacted = true
+		}
+	}
+	if !redacted {
+		return u.String(), u.RawQuery
+	}
+
+	logged := *u
+	logged.RawQuery = query.Encode()
+	return logged.String(), logged.RawQuery
+}
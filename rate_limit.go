@@ -0,0 +1,77 @@
+package logger
+
+import (
+	"context"
+	"sync"
+
+	"go.uber.org/zap/zapcore"
+	"golang.org/x/time/rate"
+)
+
+// maxRateLimitKeys bounds the number of keys with a limiter; when it is
+// reached an arbitrary key is forgotten to make room.
+const maxRateLimitKeys = 1024
+
+type keyLimiter struct {
+	limiter    *rate.Limiter
+	suppressed int
+}
+
+// rateLimiters holds the per-key limiters of ErrorwRateLimited. It is shared
+// by a logger and its children.
+type rateLimiters struct {
+	mu       sync.Mutex
+	limiters map[string]*keyLimiter
+}
+
+func newRateLimiters() *rateLimiters {
+	return &rateLimiters{limiters: make(map[string]*keyLimiter)}
+}
+
+// allow reports whether an entry for key may be logged at limit and, if so,
+// how many were dropped since the last one.
+func (r *rateLimiters) allow(key string, limit rate.Limit) (int, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	kl, ok := r.limiters[key]
+	if !ok {
+		if len(r.limiters) >= maxRateLimitKeys {
+			for k := range r.limiters {
+				delete(r.limiters, k)
+				break
+			}
+		}
+		kl = &keyLimiter{limiter: rate.NewLimiter(limit, 1)}
+		r.limiters[key] = kl
+	} else if kl.limiter.Limit() != limit {
+		kl.limiter.SetLimit(limit)
+	}
+
+	if !kl.limiter.Allow() {
+		kl.suppressed++
+		return 0, false
+	}
+	suppressed := kl.suppressed
+	kl.suppressed = 0
+	return suppressed, true
+}
+
+// ErrorwRateLimited logs at Error level at most at limit per key, e.g.
+// rate.Every(time.Minute) for once a minute per user or endpoint. Dropped
+// entries are counted and reported as "suppressed" on the next one logged.
+func (l *Logger) ErrorwRateLimited(ctx context.Context, key string, limit rate.Limit, msg string, keysAndValues ...any) {
+	if keysAndValues, ok := l.rateLimit(key, limit, keysAndValues); ok {
+		l.log(ctx, zapcore.ErrorLevel, msg, keysAndValues)
+	}
+}
+
+// rateLimit reports whether an entry for key may be logged, adding the number
+// of dropped entries to keysAndValues when there were any.
+func (l *Logger) rateLimit(key string, limit rate.Limit, keysAndValues []any) ([]any, bool) {
+	suppressed, ok := l.rateLimiters.allow(key, limit)
+	if ok && suppressed > 0 {
+		keysAndValues = append(keysAndValues, "suppressed", suppressed)
+	}
+	return keysAndValues, ok
+}
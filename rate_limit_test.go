@@ -0,0 +1,45 @@
+package logger_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cyrus-wg/go-logger"
+	"golang.org/x/time/rate"
+)
+
+func TestErrorwRateLimited(t *testing.T) {
+	l, buf := newLogger(t, logger.LoggerConfig{DisableStacktrace: true})
+	ctx := context.Background()
+
+	tests := []struct {
+		key   string
+		limit rate.Limit
+		calls int
+		want  []any // suppressed count of each logged line
+	}{
+		{"user-1", rate.Every(time.Hour), 5, []any{nil}},
+		{"user-2", rate.Every(time.Hour), 3, []any{nil}},
+		{"user-1", rate.Inf, 2, []any{float64(4), nil}},
+	}
+	for _, tt := range tests {
+		buf.Reset()
+		for range tt.calls {
+			l.ErrorwRateLimited(ctx, tt.key, tt.limit, "payment failed", "user", tt.key)
+		}
+
+		lines := decodeLines(t, buf)
+		if len(lines) != len(tt.want) {
+			t.Fatalf("%s: %d calls at %v logged %d lines, want %d", tt.key, tt.calls, tt.limit, len(lines), len(tt.want))
+		}
+		for i, line := range lines {
+			if line["user"] != tt.key || line["level"] != "ERROR" {
+				t.Errorf("%s: line %d = %v", tt.key, i, line)
+			}
+			if line["suppressed"] != tt.want[i] {
+				t.Errorf("%s: line %d suppressed = %v, want %v", tt.key, i, line["suppressed"], tt.want[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package logger
+
+import (
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const redactedValue = "[REDACTED]"
+
+// Redactor masks the value of a sensitive field, e.g. keeping only the last
+// four digits of a card number.
+type Redactor func(key string, value any) any
+
+func newRedactKeySet(keys []string) map[string]struct{} {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	set := make(map[string]struct{}, len(keys))
+	for _, key := range keys {
+		set[strings.ToLower(key)] = struct{}{}
+	}
+	return set
+}
+
+func (l *Logger) shouldRedact(key string) bool {
+	_, ok := l.redactKeys[strings.ToLower(key)]
+	return ok
+}
+
+func (l *Logger) redactValue(key string, value any) any {
+	if l.redactor != nil {
+		return l.redactor(key, value)
+	}
+	return redactedValue
+}
+
+// redactAttributes masks the values of sensitive keys in a sugared key-value
+// slice in place. Strongly-typed zap.Field entries are handled as well.
+func (l *Logger) redactAttributes(keysAndValues []any) {
+	if len(l.redactKeys) == 0 {
+		return
+	}
+
+	for i := 0; i < len(keysAndValues); {
+		if field, ok := keysAndValues[i].(zapcore.Field); ok {
+			keysAndValues[i] = l.redactField(field)
+			i++
+			continue
+		}
+
+		if i+1 >= len(keysAndValues) {
+			break
+		}
+		if key, ok := keysAndValues[i].(string); ok && l.shouldRedact(key) {
+			keysAndValues[i+1] = l.redactValue(key, keysAndValues[i+1])
+		}
+		i += 2
+	}
+}
+
+// redactField masks the value of field if its key is sensitive.
+func (l *Logger) redactField(field zapcore.Field) zapcore.Field {
+	if len(l.redactKeys) == 0 || !l.shouldRedact(field.Key) {
+		return field
+	}
+	return zap.Any(field.Key, l.redactValue(field.Key, fieldValue(field)))
+}
+
+// fieldValue returns the value a zap.Field would encode.
+func fieldValue(field zapcore.Field) any {
+	enc := zapcore.NewMapObjectEncoder()
+	field.AddTo(enc)
+	return enc.Fields[field.Key]
+}
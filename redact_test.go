@@ -0,0 +1,72 @@
+package logger_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/cyrus-wg/go-logger"
+	"go.uber.org/zap"
+)
+
+func TestRedactKeys(t *testing.T) {
+	lastFour := func(key string, value any) any {
+		s := fmt.Sprint(value)
+		if len(s) <= 4 {
+			return "****"
+		}
+		return "****" + s[len(s)-4:]
+	}
+	tests := []struct {
+		name     string
+		redactor logger.Redactor
+		log      func(l *logger.Logger, ctx context.Context)
+		want     map[string]any
+	}{
+		{
+			name: "call fields case-insensitively",
+			log: func(l *logger.Logger, ctx context.Context) {
+				l.Infow(ctx, "msg", "Password", "hunter2", "user", "alice")
+			},
+			want: map[string]any{"Password": "[REDACTED]", "user": "alice"},
+		},
+		{
+			name: "bound fields",
+			log: func(l *logger.Logger, ctx context.Context) {
+				l.WithFields("authorization", "Bearer abc").Info(ctx, "msg")
+			},
+			want: map[string]any{"authorization": "[REDACTED]"},
+		},
+		{
+			name: "zap fields",
+			log: func(l *logger.Logger, ctx context.Context) {
+				l.WithZapFields(zap.String("password", "hunter2")).LogFields(ctx, logger.InfoLevel, "msg", zap.String("card", "4111111111111111"))
+			},
+			want: map[string]any{"password": "[REDACTED]", "card": "[REDACTED]"},
+		},
+		{
+			name:     "custom redactor",
+			redactor: lastFour,
+			log: func(l *logger.Logger, ctx context.Context) {
+				l.Infow(ctx, "msg", "card", "4111111111111111")
+			},
+			want: map[string]any{"card": "****1111"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, buf := newLogger(t, logger.LoggerConfig{
+				RedactKeys: []string{"password", "Authorization", "card"},
+				Redactor:   tt.redactor,
+			})
+			tt.log(l, context.Background())
+
+			line := decodeLine(t, buf)
+			for k, v := range tt.want {
+				if line[k] != v {
+					t.Errorf("%s = %v, want %v", k, line[k], v)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package logger
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+const (
+	defaultMaxBodyBytes  = 4096
+	truncatedBodyMarker  = "...[truncated]"
+	defaultBodyMediaType = "application/json"
+)
+
+// readRequestBody returns up to maxBytes of the request body and restores
+// r.Body so the handler still reads the complete body.
+func readRequestBody(r *http.Request, maxBytes int) (string, error) {
+	var buf bytes.Buffer
+	_, err := io.Copy(&buf, io.LimitReader(r.Body, int64(maxBytes)+1))
+	r.Body = readCloser{io.MultiReader(bytes.NewReader(buf.Bytes()), r.Body), r.Body}
+	if err != nil {
+		return "", err
+	}
+
+	if buf.Len() > maxBytes {
+		return string(buf.Bytes()[:maxBytes]) + truncatedBodyMarker, nil
+	}
+	return buf.String(), nil
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// hasBodyMediaType reports whether the request content type is one of
+// mediaTypes, ignoring parameters such as charset.
+func hasBodyMediaType(r *http.Request, mediaTypes []string) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+	for _, t := range mediaTypes {
+		if strings.EqualFold(mediaType, t) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,52 @@
+package logger_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cyrus-wg/go-logger"
+)
+
+func TestMiddlewareRequestBody(t *testing.T) {
+	largeJSON := `{"items":[` + strings.Repeat(`{"sku":"A-1","qty":1},`, 500) + `{"sku":"B-2","qty":2}]}`
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		maxBytes    int
+		want        any
+	}{
+		{"small JSON", "application/json", `{"event":"paid"}`, 0, `{"event":"paid"}`},
+		{"large JSON", "application/json; charset=utf-8", largeJSON, 0, largeJSON[:4096] + "...[truncated]"},
+		{"custom limit", "application/json", `{"event":"paid"}`, 8, `{"event"...[truncated]`},
+		{"other content type", "text/plain", "hello", 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, buf := newLogger(t, logger.LoggerConfig{})
+			var handlerBody string
+			handler := l.LoggerMiddlewareWithConfig(logger.MiddlewareConfig{
+				LogRequestDetails: true,
+				LogRequestBody:    true,
+				MaxBodyBytes:      tt.maxBytes,
+			})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				handlerBody = string(body)
+			}))
+			req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			if handlerBody != tt.body {
+				t.Errorf("handler read %d bytes, want the complete %d byte body", len(handlerBody), len(tt.body))
+			}
+			details, _ := decodeLine(t, buf)["details"].(map[string]any)
+			if got := details["body"]; got != tt.want {
+				t.Errorf("logged body = %.80v, want %.80v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package logger
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// RequestScope accumulates fields and timed events during a request and logs
+// them as one summary entry, instead of many small lines. It is safe for
+// concurrent use.
+type RequestScope struct {
+	logger *Logger
+	ctx    context.Context
+	start  time.Time
+
+	mu       sync.Mutex
+	fields   []any
+	events   []scopeEvent
+	finished bool
+}
+
+type scopeEvent struct {
+	name string
+	at   time.Duration
+}
+
+func (e scopeEvent) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("name", e.name)
+	enc.AddFloat64("at_ms", float64(e.at)/float64(time.Millisecond))
+	return nil
+}
+
+type scopeEvents []scopeEvent
+
+func (e scopeEvents) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for _, event := range e {
+		if err := enc.AppendObject(event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NewRequestScope starts a scope whose summary is logged with ctx.
+func (l *Logger) NewRequestScope(ctx context.Context) *RequestScope {
+	return &RequestScope{logger: l, ctx: ctx, start: time.Now()}
+}
+
+// RequestScopeFromContext returns the scope the middleware stored in ctx when
+// MiddlewareConfig.RequestScope is set, or nil. The methods of a nil scope do
+// nothing, so handlers can use the result without checking it.
+func RequestScopeFromContext(ctx context.Context) *RequestScope {
+	scope, _ := ctx.Value(requestScopeKey).(*RequestScope)
+	return scope
+}
+
+// Add sets a field of the summary. A key added twice is logged twice. Fields
+// added after Finish are dropped.
+func (s *RequestScope) Add(key string, value any) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.finished {
+		return
+	}
+	s.fields = append(s.fields, key, value)
+}
+
+// Event records name in the events timeline with the time elapsed since the
+// scope started. Events recorded after Finish are dropped.
+func (s *RequestScope) Event(name string) {
+	if s == nil {
+		return
+	}
+	at := time.Since(s.start)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.finished {
+		return
+	}
+	s.events = append(s.events, scopeEvent{name: name, at: at})
+}
+
+// Finish logs msg at Info level with the accumulated fields and, when there
+// are any, the events under "events". Only the first call logs.
+func (s *RequestScope) Finish(msg string) {
+	if s == nil {
+		return
+	}
+	if keysAndValues, ok := s.finish(); ok {
+		s.logger.log(s.ctx, zapcore.InfoLevel, msg, keysAndValues)
+	}
+}
+
+// finish marks the scope finished and returns its summary fields, or false
+// if it was already finished.
+func (s *RequestScope) finish() ([]any, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.finished {
+		return nil, false
+	}
+	s.finished = true
+
+	// Cloned so appending the events never writes into the backing array
+	// of s.fields
+	keysAndValues := slices.Clone(s.fields)
+	if len(s.events) > 0 {
+		keysAndValues = append(keysAndValues, "events", scopeEvents(s.events))
+	}
+	return keysAndValues, true
+}
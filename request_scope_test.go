@@ -0,0 +1,140 @@
+package logger_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cyrus-wg/go-logger"
+)
+
+func TestRequestScopeMiddleware(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  logger.MiddlewareConfig
+		message string
+	}{
+		{"LogCompleteTime", logger.MiddlewareConfig{LogCompleteTime: true}, "Request completed"},
+		{"SingleLine", logger.MiddlewareConfig{LogMode: logger.SingleLine}, "Request completed"},
+		{"AccessLog", logger.MiddlewareConfig{AccessLog: true}, "access"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, buf := newLogger(t, logger.LoggerConfig{})
+			tt.config.RequestScope = true
+			handler := l.LoggerMiddlewareWithConfig(tt.config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				scope := logger.RequestScopeFromContext(r.Context())
+				scope.Add("cache", "hit")
+				scope.Event("db")
+			}))
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orders", nil))
+
+			var completed map[string]any
+			for _, line := range decodeLines(t, buf) {
+				if line["message"] == tt.message {
+					completed = line
+				}
+			}
+			if completed == nil {
+				t.Fatalf("no %q entry:\n%s", tt.message, buf)
+			}
+			if completed["cache"] != "hit" {
+				t.Errorf("cache = %v, want hit", completed["cache"])
+			}
+			events, _ := completed["events"].([]any)
+			if len(events) != 1 {
+				t.Fatalf("events = %v, want one", completed["events"])
+			}
+			if name := events[0].(map[string]any)["name"]; name != "db" {
+				t.Errorf("event name = %v, want db", name)
+			}
+		})
+	}
+}
+
+func TestRequestScope(t *testing.T) {
+	l, buf := newLogger(t, logger.LoggerConfig{})
+	scope := l.NewRequestScope(l.SetRequestID(context.Background(), "req-1"))
+	scope.Add("order", 7)
+	for _, name := range []string{"validated", "charged", "shipped"} {
+		time.Sleep(time.Millisecond)
+		scope.Event(name)
+	}
+	scope.Finish("order processed")
+	scope.Finish("finished twice")
+	scope.Event("late")
+
+	lines := decodeLines(t, buf)
+	if len(lines) != 1 {
+		t.Fatalf("got %d lines, want 1:\n%s", len(lines), buf)
+	}
+	line := lines[0]
+	want := map[string]any{"message": "order processed", "request_id": "req-1", "order": float64(7)}
+	for k, v := range want {
+		if line[k] != v {
+			t.Errorf("%s = %v, want %v", k, line[k], v)
+		}
+	}
+
+	events, _ := line["events"].([]any)
+	var names []string
+	previous := 0.0
+	for _, event := range events {
+		event, _ := event.(map[string]any)
+		name, _ := event["name"].(string)
+		names = append(names, name)
+		at, _ := event["at_ms"].(float64)
+		if at <= previous {
+			t.Errorf("%s at_ms = %v, want after %v", name, at, previous)
+		}
+		previous = at
+	}
+	if want := []string{"validated", "charged", "shipped"}; !slices.Equal(names, want) {
+		t.Errorf("events = %v, want %v", names, want)
+	}
+
+	var nilScope *logger.RequestScope
+	nilScope.Add("k", "v")
+	nilScope.Event("e")
+	nilScope.Finish("msg")
+}
+
+// TestRequestScopeAddDuringFinish is meant to be run with -race: fields added
+// while the summary is written must not touch the logged slice.
+func TestRequestScopeAddDuringFinish(t *testing.T) {
+	l, buf := newLogger(t, logger.LoggerConfig{})
+	scope := l.NewRequestScope(context.Background())
+	// Three fields leave spare capacity in the slice, which the events and
+	// later fields would both be appended into without a copy
+	for i := range 3 {
+		scope.Add("before", i)
+	}
+	scope.Event("started")
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 100 {
+				scope.Add("during", i)
+				scope.Event("during")
+			}
+		}()
+	}
+	scope.Finish("done")
+	wg.Wait()
+	scope.Add("after", 1)
+
+	lines := decodeLines(t, buf)
+	if len(lines) != 1 {
+		t.Fatalf("got %d lines, want 1", len(lines))
+	}
+	if _, ok := lines[0]["after"]; ok {
+		t.Errorf("field added after Finish was logged: %v", lines[0])
+	}
+}
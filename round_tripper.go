@@ -0,0 +1,49 @@
+package logger
+
+import (
+	"net/http"
+	"time"
+)
+
+// RoundTripper wraps base, http.DefaultTransport if nil, so outbound requests
+// carry the request ID of their context in the X-Request-ID header and each
+// call is logged with its method, URL, status and latency.
+func (l *Logger) RoundTripper(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &loggingRoundTripper{logger: l, base: base}
+}
+
+type loggingRoundTripper struct {
+	logger *Logger
+	base   http.RoundTripper
+}
+
+func (t *loggingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	if requestId, ok := t.logger.GetRequestID(ctx); ok && req.Header.Get(defaultRequestIDHeader) == "" {
+		// A RoundTripper must not modify the caller's request
+		req = req.Clone(ctx)
+		req.Header.Set(defaultRequestIDHeader, requestId)
+	}
+
+	startTime := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	latency := time.Since(startTime)
+
+	fields := []any{
+		"method", req.Method,
+		"url", req.URL.String(),
+		"latency", latency.String(),
+		"latency_ms", float64(latency) / float64(time.Millisecond),
+	}
+	if err != nil {
+		t.logger.Errorw(ctx, "Outbound request failed", append(fields, "error", err)...)
+		return resp, err
+	}
+
+	fields = append(fields, "status", resp.StatusCode)
+	t.logger.Logw(ctx, completionLevel(resp.StatusCode), "Outbound request completed", fields...)
+	return resp, nil
+}
@@ -0,0 +1,98 @@
+package logger_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cyrus-wg/go-logger"
+)
+
+func TestRoundTripper(t *testing.T) {
+	var gotHeader string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeader = r.Header.Get("X-Request-ID")
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name       string
+		path       string
+		requestID  string
+		header     string
+		wantHeader string
+		wantStatus float64
+		wantLevel  string
+	}{
+		{"propagates request ID", "/orders", "req-1", "", "req-1", 200, "INFO"},
+		{"keeps caller header", "/orders", "req-1", "upstream-1", "upstream-1", 200, "INFO"},
+		{"no request ID", "/orders", "", "", "", 200, "INFO"},
+		{"client error", "/missing", "req-2", "", "req-2", 404, "WARN"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, buf := newLogger(t, logger.LoggerConfig{})
+			client := &http.Client{Transport: l.RoundTripper(nil)}
+			ctx := context.Background()
+			if tt.requestID != "" {
+				ctx = l.SetRequestID(ctx, tt.requestID)
+			}
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+tt.path, nil)
+			if err != nil {
+				t.Fatalf("NewRequest: %v", err)
+			}
+			if tt.header != "" {
+				req.Header.Set("X-Request-ID", tt.header)
+			}
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("Do: %v", err)
+			}
+			resp.Body.Close()
+
+			if gotHeader != tt.wantHeader {
+				t.Errorf("server got X-Request-ID %q, want %q", gotHeader, tt.wantHeader)
+			}
+			if tt.header == "" && req.Header.Get("X-Request-ID") != "" {
+				t.Error("RoundTripper modified the caller's request")
+			}
+			line := decodeLine(t, buf)
+			want := map[string]any{
+				"message": "Outbound request completed",
+				"level":   tt.wantLevel,
+				"method":  "GET",
+				"url":     server.URL + tt.path,
+				"status":  tt.wantStatus,
+			}
+			for k, v := range want {
+				if line[k] != v {
+					t.Errorf("%s = %v, want %v", k, line[k], v)
+				}
+			}
+			if _, ok := line["latency_ms"].(float64); !ok {
+				t.Errorf("latency_ms = %v, want a number", line["latency_ms"])
+			}
+		})
+	}
+}
+
+func TestRoundTripperError(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	l, buf := newLogger(t, logger.LoggerConfig{DisableStacktrace: true})
+	client := &http.Client{Transport: l.RoundTripper(nil)}
+	if _, err := client.Get(url); err == nil {
+		t.Fatal("Get on a closed server succeeded")
+	}
+
+	line := decodeLine(t, buf)
+	if line["message"] != "Outbound request failed" || line["level"] != "ERROR" || line["error"] == nil {
+		t.Errorf("logged %v, want an Error entry with the error", line)
+	}
+}
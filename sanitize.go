@@ -0,0 +1,33 @@
+package logger
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// sanitizeMessage escapes line breaks and other control characters so a
+// message can't start what looks like a new log line.
+func sanitizeMessage(msg string) string {
+	if !strings.ContainsFunc(msg, unicode.IsControl) {
+		return msg
+	}
+
+	var b strings.Builder
+	b.Grow(len(msg) + 8)
+	for _, r := range msg {
+		switch {
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case unicode.IsControl(r):
+			fmt.Fprintf(&b, `\u%04x`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,79 @@
+package logger
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// FlushOnSignal closes l, which flushes it first, when the process receives
+// one of signals, SIGINT and SIGTERM by default, until ctx is done. It then
+// stops listening and raises the signal again so the usual handling, such as
+// terminating the process, still happens. The logger must not be used after
+// the signal. Applications that handle these signals themselves should use
+// CloseOnSignal, or call Close at the end of their own shutdown path, as
+// their handler would see each signal twice.
+func (l *Logger) FlushOnSignal(ctx context.Context, signals ...os.Signal) {
+	l.CloseOnSignal(ctx, raise, signals...)
+}
+
+// CloseOnSignal is like FlushOnSignal but calls fn with the signal after
+// closing l instead of raising it again, e.g. to cancel the context the
+// application shuts down with. A nil fn only closes l.
+func (l *Logger) CloseOnSignal(ctx context.Context, fn func(os.Signal), signals ...os.Signal) {
+	if len(signals) == 0 {
+		signals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, signals...)
+
+	go func() {
+		defer signal.Stop(ch)
+
+		select {
+		case <-ctx.Done():
+		case sig := <-ch:
+			signal.Stop(ch)
+			_ = l.Close()
+			if fn != nil {
+				fn(sig)
+			}
+		}
+	}()
+}
+
+// raise sends sig to the current process.
+func raise(sig os.Signal) {
+	if process, err := os.FindProcess(os.Getpid()); err == nil {
+		_ = process.Signal(sig)
+	}
+}
+
+// RotateOnSignal calls Rotate each time the process receives one of signals,
+// SIGHUP by default, until ctx is done, e.g. for logrotate's postrotate
+// hook. Failures are logged at Error level.
+func (l *Logger) RotateOnSignal(ctx context.Context, signals ...os.Signal) {
+	if len(signals) == 0 {
+		signals = []os.Signal{syscall.SIGHUP}
+	}
+
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, signals...)
+
+	go func() {
+		defer signal.Stop(ch)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ch:
+				if err := l.Rotate(); err != nil {
+					l.Errorw(ctx, "Failed to rotate log file", "error", err)
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,186 @@
+//go:build unix
+
+package logger_test
+
+import (
+	"bytes"
+	"context"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/cyrus-wg/go-logger"
+	"go.uber.org/zap"
+)
+
+// lockedBuffer is a bytes.Buffer safe to write from the flushing goroutine.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) bytes() *bytes.Buffer {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return bytes.NewBuffer(slices.Clone(b.buf.Bytes()))
+}
+
+// closeSink is an OutputPaths sink that records when the logger closes it.
+type closeSink struct {
+	lockedBuffer
+	closed atomic.Bool
+}
+
+func (s *closeSink) Sync() error { return nil }
+
+func (s *closeSink) Close() error {
+	s.closed.Store(true)
+	return nil
+}
+
+var closeSinks sync.Map
+
+func init() {
+	err := zap.RegisterSink("closesink", func(u *url.URL) (zap.Sink, error) {
+		sink, _ := closeSinks.Load(u.Host)
+		return sink.(*closeSink), nil
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
+// newAsyncSignalLogger returns an async logger that only writes when it is
+// flushed, writing to a sink that records when it is closed.
+func newAsyncSignalLogger(t *testing.T) (*logger.Logger, *closeSink) {
+	t.Helper()
+	out := &closeSink{}
+	closeSinks.Store(t.Name(), out)
+	l, err := logger.NewLogger(logger.LoggerConfig{
+		OutputPaths:        []string{"closesink://" + t.Name()},
+		Encoding:           "json",
+		Async:              true,
+		AsyncFlushInterval: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("NewLogger: %v", err)
+	}
+	return l, out
+}
+
+func TestFlushOnSignal(t *testing.T) {
+	l, out := newAsyncSignalLogger(t)
+
+	// The application's own handler keeps the re-raised signal from
+	// terminating the test binary.
+	app := make(chan os.Signal, 2)
+	signal.Notify(app, syscall.SIGUSR1)
+	defer signal.Stop(app)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	l.FlushOnSignal(ctx, syscall.SIGUSR1)
+
+	l.Info(ctx, "buffered")
+	if got := messages(t, out.bytes()); len(got) != 0 {
+		t.Fatalf("async entry written before the signal: %v", got)
+	}
+	if err := syscall.Kill(os.Getpid(), syscall.SIGUSR1); err != nil {
+		t.Fatalf("Kill: %v", err)
+	}
+
+	// The application sees the signal once when it is sent and once when
+	// FlushOnSignal raises it again after flushing.
+	for range 2 {
+		select {
+		case <-app:
+		case <-time.After(5 * time.Second):
+			t.Fatal("signal was not delivered to the application handler twice")
+		}
+	}
+	if got := messages(t, out.bytes()); !slices.Equal(got, []string{"buffered"}) {
+		t.Errorf("after the signal got %v, want [buffered]", got)
+	}
+	if !out.closed.Load() {
+		t.Error("logger was not closed before the signal was raised again")
+	}
+}
+
+func TestCloseOnSignal(t *testing.T) {
+	l, out := newAsyncSignalLogger(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	received := make(chan os.Signal, 1)
+	l.CloseOnSignal(ctx, func(sig os.Signal) {
+		// Checked here, as the logger must be closed before fn runs
+		if !out.closed.Load() {
+			t.Error("fn ran before the logger was closed")
+		}
+		received <- sig
+	}, syscall.SIGUSR2)
+
+	l.Info(ctx, "buffered")
+	if err := syscall.Kill(os.Getpid(), syscall.SIGUSR2); err != nil {
+		t.Fatalf("Kill: %v", err)
+	}
+
+	// The signal is not raised again, so without fn's channel nothing else
+	// handles it and the test binary keeps running.
+	select {
+	case sig := <-received:
+		if sig != syscall.SIGUSR2 {
+			t.Errorf("fn got %v, want %v", sig, syscall.SIGUSR2)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("fn was not called")
+	}
+	if got := messages(t, out.bytes()); !slices.Equal(got, []string{"buffered"}) {
+		t.Errorf("after the signal got %v, want [buffered]", got)
+	}
+}
+
+func TestRotateOnSignal(t *testing.T) {
+	dir := t.TempDir()
+	l, err := logger.NewLogger(logger.LoggerConfig{RotationConfig: &logger.RotationConfig{Filename: filepath.Join(dir, "app.log")}})
+	if err != nil {
+		t.Fatalf("NewLogger: %v", err)
+	}
+	defer l.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	l.RotateOnSignal(ctx)
+	l.Info(ctx, "before")
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+		t.Fatalf("Kill: %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		files, err := os.ReadDir(dir)
+		if err != nil {
+			t.Fatalf("ReadDir: %v", err)
+		}
+		if len(files) == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("got %d files %v after SIGHUP, want the current file and one backup", len(files), files)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,131 @@
+package logger
+
+import (
+	"io"
+	"os"
+
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// SinkConfig is an additional log destination with its own minimum level.
+type SinkConfig struct {
+	Writer   io.Writer
+	Level    Level  // Minimum level written to this sink, zero uses the logger level
+	Encoding string // "json", "console" or "logfmt", empty uses the logger encoding
+}
+
+// sinkControls acts on the destinations opened by buildCore.
+type sinkControls struct {
+	// close stops async buffering and closes the files opened for the core
+	close func() error
+	// rotate rotates the RotationConfig file, nil when there is none
+	rotate func() error
+}
+
+// buildCore creates one core per configured destination and tees them
+// together. When no destination is configured logs go to stderr. Sinks with
+// their own Encoding get an encoder built from encoderConfig.
+func buildCore(config LoggerConfig, encoder zapcore.Encoder, encoderConfig zapcore.EncoderConfig, level zapcore.LevelEnabler) (zapcore.Core, sinkControls, error) {
+	var cores []zapcore.Core
+	var closers []func() error
+	var controls sinkControls
+
+	// writeSyncer makes ws safe for concurrent use and, in async mode,
+	// buffers its writes. BufferedWriteSyncer serializes writes itself.
+	writeSyncer := func(ws zapcore.WriteSyncer) zapcore.WriteSyncer {
+		if !config.Async {
+			return zapcore.Lock(ws)
+		}
+		buffered := &zapcore.BufferedWriteSyncer{
+			WS:            ws,
+			Size:          config.AsyncBufferSize,
+			FlushInterval: config.AsyncFlushInterval,
+		}
+		closers = append(closers, buffered.Stop)
+		return buffered
+	}
+
+	if rotation := config.RotationConfig; rotation != nil {
+		file := &lumberjack.Logger{
+			Filename:   rotation.Filename,
+			MaxSize:    rotation.MaxSizeMB,
+			MaxBackups: rotation.MaxBackups,
+			MaxAge:     rotation.MaxAgeDays,
+			Compress:   rotation.Compress,
+		}
+		cores = append(cores, zapcore.NewCore(encoder, writeSyncer(zapcore.AddSync(file)), level))
+		closers = append(closers, file.Close)
+		controls.rotate = file.Rotate
+	} else if config.SplitOutput {
+		cores = append(cores,
+			zapcore.NewCore(encoder, writeSyncer(os.Stdout), zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
+				return lvl < zapcore.WarnLevel && level.Enabled(lvl)
+			})),
+			zapcore.NewCore(encoder, writeSyncer(os.Stderr), zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
+				return lvl >= zapcore.WarnLevel && level.Enabled(lvl)
+			})),
+		)
+	} else if config.Output != nil {
+		cores = append(cores, zapcore.NewCore(encoder, writeSyncer(zapcore.AddSync(config.Output)), level))
+	}
+
+	if len(config.OutputPaths) > 0 {
+		sink, cleanup, err := zap.Open(config.OutputPaths...)
+		if err != nil {
+			return nil, sinkControls{}, err
+		}
+		cores = append(cores, zapcore.NewCore(encoder, writeSyncer(sink), level))
+		closers = append(closers, func() error {
+			cleanup()
+			return nil
+		})
+	}
+
+	for _, sinkConfig := range config.Sinks {
+		enabler, err := sinkLevelEnabler(level, sinkConfig.Level)
+		if err != nil {
+			return nil, sinkControls{}, err
+		}
+		sinkEncoder := encoder
+		if sinkConfig.Encoding != "" {
+			sinkLoggerConfig := config
+			sinkLoggerConfig.Encoding = sinkConfig.Encoding
+			if sinkEncoder, err = newEncoder(sinkLoggerConfig, encoderConfig); err != nil {
+				return nil, sinkControls{}, err
+			}
+		}
+		cores = append(cores, zapcore.NewCore(sinkEncoder, writeSyncer(zapcore.AddSync(sinkConfig.Writer)), enabler))
+	}
+
+	if len(cores) == 0 {
+		cores = append(cores, zapcore.NewCore(encoder, writeSyncer(os.Stderr), level))
+	}
+
+	controls.close = func() error {
+		var err error
+		for _, closer := range closers {
+			err = multierr.Append(err, closer())
+		}
+		return err
+	}
+	return zapcore.NewTee(cores...), controls, nil
+}
+
+// sinkLevelEnabler enables entries allowed by both the logger level and the
+// sink's own minimum level.
+func sinkLevelEnabler(level zapcore.LevelEnabler, minLevel Level) (zapcore.LevelEnabler, error) {
+	if minLevel == 0 {
+		return level, nil
+	}
+
+	zl, err := minLevel.zapLevel()
+	if err != nil {
+		return nil, err
+	}
+	return zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
+		return lvl >= zl && level.Enabled(lvl)
+	}), nil
+}
@@ -0,0 +1,401 @@
+package logger_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/cyrus-wg/go-logger"
+)
+
+func TestOutput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	tests := []struct {
+		name   string
+		config func(buf *bytes.Buffer) logger.LoggerConfig
+		read   func(t *testing.T, buf *bytes.Buffer) []byte
+	}{
+		{
+			name: "Output",
+			config: func(buf *bytes.Buffer) logger.LoggerConfig {
+				return logger.LoggerConfig{Output: buf}
+			},
+			read: func(t *testing.T, buf *bytes.Buffer) []byte { return buf.Bytes() },
+		},
+		{
+			name: "OutputPaths",
+			config: func(*bytes.Buffer) logger.LoggerConfig {
+				return logger.LoggerConfig{OutputPaths: []string{path}}
+			},
+			read: func(t *testing.T, _ *bytes.Buffer) []byte {
+				data, err := os.ReadFile(path)
+				if err != nil {
+					t.Fatalf("ReadFile: %v", err)
+				}
+				return data
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			config := tt.config(&buf)
+			config.Level = logger.WarnLevel
+			config.Encoding = "json"
+			l, err := logger.NewLogger(config)
+			if err != nil {
+				t.Fatalf("NewLogger: %v", err)
+			}
+			defer l.Close()
+
+			l.Info(context.Background(), "filtered")
+			l.Warn(context.Background(), "written")
+			if err := l.Flush(); err != nil {
+				t.Fatalf("Flush: %v", err)
+			}
+
+			var line map[string]any
+			if err := json.Unmarshal(tt.read(t, &buf), &line); err != nil {
+				t.Fatalf("output is not one JSON line: %v", err)
+			}
+			if line["message"] != "written" {
+				t.Errorf("message = %v, want written", line["message"])
+			}
+		})
+	}
+}
+
+func TestRotationRollover(t *testing.T) {
+	dir := t.TempDir()
+	l, _ := newLogger(t, logger.LoggerConfig{
+		RotationConfig: &logger.RotationConfig{
+			Filename:  filepath.Join(dir, "app.log"),
+			MaxSizeMB: 1,
+		},
+	})
+
+	payload := strings.Repeat("x", 1024)
+	for i := range 1500 {
+		l.Infow(context.Background(), fmt.Sprint("line ", i), "payload", payload)
+	}
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir: %v", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("got %d files %v, want the current file and one backup", len(files), files)
+	}
+	for _, file := range files {
+		info, err := file.Info()
+		if err != nil {
+			t.Fatalf("Info: %v", err)
+		}
+		if info.Size() > 1<<20 {
+			t.Errorf("%s is %d bytes, larger than MaxSizeMB", file.Name(), info.Size())
+		}
+	}
+}
+
+// syncWriter is an io.Writer whose Sync returns err, like a file.
+type syncWriter struct {
+	bytes.Buffer
+	err error
+}
+
+func (w *syncWriter) Sync() error {
+	return w.err
+}
+
+func TestFlushError(t *testing.T) {
+	errClosed := &fs.PathError{Op: "sync", Path: "/var/log/app.log", Err: os.ErrClosed}
+	errStderr := &fs.PathError{Op: "sync", Path: os.Stderr.Name(), Err: syscall.EINVAL}
+	tests := []struct {
+		name            string
+		syncErr         error
+		wantFlush       error
+		wantIgnoringStd error
+	}{
+		{"no error", nil, nil, nil},
+		{"closed file", errClosed, errClosed, errClosed},
+		{"stderr", errStderr, errStderr, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, err := logger.NewLogger(logger.LoggerConfig{Output: &syncWriter{err: tt.syncErr}})
+			if err != nil {
+				t.Fatalf("NewLogger: %v", err)
+			}
+			if err := l.Flush(); !errors.Is(err, tt.wantFlush) || (err == nil) != (tt.wantFlush == nil) {
+				t.Errorf("Flush = %v, want %v", err, tt.wantFlush)
+			}
+			if err := l.FlushIgnoringStderr(); !errors.Is(err, tt.wantIgnoringStd) || (err == nil) != (tt.wantIgnoringStd == nil) {
+				t.Errorf("FlushIgnoringStderr = %v, want %v", err, tt.wantIgnoringStd)
+			}
+		})
+	}
+}
+
+func TestSinks(t *testing.T) {
+	var all, warn bytes.Buffer
+	l, err := logger.NewLogger(logger.LoggerConfig{
+		Level:    logger.DebugLevel,
+		Encoding: "json",
+		Sinks: []logger.SinkConfig{
+			{Writer: &all},
+			{Writer: &warn, Level: logger.WarnLevel},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewLogger: %v", err)
+	}
+	ctx := context.Background()
+	l.Debug(ctx, "debug")
+	l.Info(ctx, "info")
+	l.Warn(ctx, "warn")
+	l.Error(ctx, "error")
+
+	tests := []struct {
+		name string
+		buf  *bytes.Buffer
+		want []string
+	}{
+		{"all levels", &all, []string{"debug", "info", "warn", "error"}},
+		{"warn and above", &warn, []string{"warn", "error"}},
+	}
+	for _, tt := range tests {
+		if got := messages(t, tt.buf); !slices.Equal(got, tt.want) {
+			t.Errorf("%s sink got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAsyncFlush(t *testing.T) {
+	var buf bytes.Buffer
+	l, err := logger.NewLogger(logger.LoggerConfig{
+		Output:             &buf,
+		Encoding:           "json",
+		Async:              true,
+		AsyncFlushInterval: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("NewLogger: %v", err)
+	}
+	defer l.Close()
+
+	l.Info(context.Background(), "buffered")
+	if buf.Len() != 0 {
+		t.Fatalf("async entry written before Flush: %s", buf.String())
+	}
+	if err := l.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if got := messages(t, &buf); !slices.Equal(got, []string{"buffered"}) {
+		t.Errorf("after Flush got %v, want [buffered]", got)
+	}
+}
+
+// openHandles counts the file descriptors of the process open on path.
+func openHandles(t *testing.T, path string) int {
+	t.Helper()
+	fds, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		t.Skipf("cannot list open files: %v", err)
+	}
+	n := 0
+	for _, fd := range fds {
+		if target, err := os.Readlink(filepath.Join("/proc/self/fd", fd.Name())); err == nil && target == path {
+			n++
+		}
+	}
+	return n
+}
+
+func TestCloseReleasesFiles(t *testing.T) {
+	tests := []struct {
+		name   string
+		config func(path string) logger.LoggerConfig
+	}{
+		{"RotationConfig", func(path string) logger.LoggerConfig {
+			return logger.LoggerConfig{RotationConfig: &logger.RotationConfig{Filename: path}}
+		}},
+		{"OutputPaths", func(path string) logger.LoggerConfig {
+			return logger.LoggerConfig{OutputPaths: []string{path}}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "app.log")
+			l, err := logger.NewLogger(tt.config(path))
+			if err != nil {
+				t.Fatalf("NewLogger: %v", err)
+			}
+			l.Info(context.Background(), "written")
+			if openHandles(t, path) == 0 {
+				t.Fatal("log file is not open after writing")
+			}
+
+			if err := l.Close(); err != nil {
+				t.Fatalf("Close: %v", err)
+			}
+			if n := openHandles(t, path); n != 0 {
+				t.Errorf("%d handles still open on the log file after Close", n)
+			}
+			if err := os.Remove(path); err != nil {
+				t.Errorf("Remove after Close: %v", err)
+			}
+		})
+	}
+}
+
+// redirectStdio points os.Stdout and os.Stderr at files in a temporary
+// directory until the test ends, returning the files.
+func redirectStdio(t *testing.T) (stdout, stderr *os.File) {
+	t.Helper()
+	dir := t.TempDir()
+	files := make([]*os.File, 2)
+	for i, name := range []string{"stdout", "stderr"} {
+		file, err := os.Create(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		t.Cleanup(func() { file.Close() })
+		files[i] = file
+	}
+
+	origStdout, origStderr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = files[0], files[1]
+	t.Cleanup(func() { os.Stdout, os.Stderr = origStdout, origStderr })
+	return files[0], files[1]
+}
+
+func TestSplitOutput(t *testing.T) {
+	stdout, stderr := redirectStdio(t)
+	l, err := logger.NewLogger(logger.LoggerConfig{Level: logger.DebugLevel, Encoding: "json", SplitOutput: true})
+	if err != nil {
+		t.Fatalf("NewLogger: %v", err)
+	}
+	ctx := context.Background()
+	l.Debug(ctx, "debug")
+	l.Info(ctx, "info")
+	l.Warn(ctx, "warn")
+	l.Error(ctx, "error")
+	if err := l.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	tests := []struct {
+		file *os.File
+		want []string
+	}{
+		{stdout, []string{"debug", "info"}},
+		{stderr, []string{"warn", "error"}},
+	}
+	for _, tt := range tests {
+		data, err := os.ReadFile(tt.file.Name())
+		if err != nil {
+			t.Fatalf("ReadFile: %v", err)
+		}
+		if got := messages(t, bytes.NewBuffer(data)); !slices.Equal(got, tt.want) {
+			t.Errorf("%s got %v, want %v", filepath.Base(tt.file.Name()), got, tt.want)
+		}
+	}
+}
+
+func TestRotate(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	l, _ := newLogger(t, logger.LoggerConfig{RotationConfig: &logger.RotationConfig{Filename: path}})
+	defer l.Close()
+
+	ctx := context.Background()
+	l.Info(ctx, "before")
+	if err := l.Rotate(); err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+	l.Info(ctx, "after")
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir: %v", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("got %d files %v, want the current file and one backup", len(files), files)
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			t.Fatalf("ReadFile: %v", err)
+		}
+		want := []string{"before"}
+		if file.Name() == "app.log" {
+			want = []string{"after"}
+		}
+		if got := messages(t, bytes.NewBuffer(data)); !slices.Equal(got, want) {
+			t.Errorf("%s has %v, want %v", file.Name(), got, want)
+		}
+	}
+
+	plain, _ := newLogger(t, logger.LoggerConfig{})
+	if err := plain.Rotate(); err != nil {
+		t.Errorf("Rotate without a rotating sink = %v, want nil", err)
+	}
+}
+
+func TestSinkEncodings(t *testing.T) {
+	var jsonBuf, consoleBuf, logfmtBuf bytes.Buffer
+	l, err := logger.NewLogger(logger.LoggerConfig{
+		Level: logger.InfoLevel,
+		Sinks: []logger.SinkConfig{
+			{Writer: &jsonBuf, Encoding: "json"},
+			{Writer: &consoleBuf, Encoding: "console"},
+			{Writer: &logfmtBuf, Encoding: "logfmt", Level: logger.WarnLevel},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewLogger: %v", err)
+	}
+	ctx := l.SetRequestID(context.Background(), "req-1")
+	l.Infow(ctx, "charge created", "amount", 42)
+	l.Warnw(ctx, "retrying", "attempt", 2)
+
+	tests := []struct {
+		name     string
+		buf      *bytes.Buffer
+		wantJSON bool
+		lines    int
+		contains []string
+	}{
+		{"json", &jsonBuf, true, 2, []string{`"message":"charge created"`, `"request_id":"req-1"`, `"amount":42`}},
+		{"console", &consoleBuf, false, 2, []string{"\x1b[34mINFO\x1b[0m", "\tcharge created\t", `"request_id": "req-1"`}},
+		{"logfmt", &logfmtBuf, false, 1, []string{"level=WARN", "message=retrying", "request_id=req-1", "attempt=2"}},
+	}
+	for _, tt := range tests {
+		output := tt.buf.String()
+		if got := strings.Count(output, "\n"); got != tt.lines {
+			t.Errorf("%s sink wrote %d lines, want %d:\n%s", tt.name, got, tt.lines, output)
+		}
+		firstLine, _, _ := strings.Cut(output, "\n")
+		if got := json.Valid([]byte(firstLine)); got != tt.wantJSON {
+			t.Errorf("%s sink JSON = %t, want %t:\n%s", tt.name, got, tt.wantJSON, output)
+		}
+		for _, want := range tt.contains {
+			if !strings.Contains(output, want) {
+				t.Errorf("%s sink output lacks %q:\n%s", tt.name, want, output)
+			}
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// slogHandler is a slog.Handler that writes records through a Logger, so
+// they get the same sinks and context fields as the Logger's own methods.
+// Groups are kept in groups until an attribute is added under them, so a
+// group without attributes is not logged.
+type slogHandler struct {
+	logger *Logger
+	fields []zap.Field
+	groups []string
+}
+
+// SlogHandler returns a slog.Handler writing through l, e.g.
+// slog.New(l.SlogHandler()). Records logged with a context get the same
+// request ID, user and other context fields as l's own methods, and groups
+// are nested like WithGroup.
+func (l *Logger) SlogHandler() slog.Handler {
+	return &slogHandler{logger: l}
+}
+
+func (h *slogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	if ctx != nil && IsTraced(ctx) {
+		return true
+	}
+	return h.logger.base.Core().Enabled(slogLevel(level))
+}
+
+func (h *slogHandler) Handle(ctx context.Context, record slog.Record) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	entry := h.logger.check(ctx, levelFromZap(slogLevel(record.Level)), record.Message)
+	if entry == nil {
+		return nil
+	}
+	// A zero time is ignored, as slog requires; the entry keeps the time
+	// it was checked at rather than logging the zero value.
+	if !record.Time.IsZero() {
+		entry.entry.Time = record.Time
+	}
+	if entry.entry.Caller.Defined && record.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{record.PC}).Next()
+		entry.entry.Caller = zapcore.EntryCaller{
+			Defined:  true,
+			PC:       frame.PC,
+			File:     frame.File,
+			Line:     frame.Line,
+			Function: frame.Function,
+		}
+	}
+
+	var attrs []zap.Field
+	record.Attrs(func(attr slog.Attr) bool {
+		attrs = appendSlogAttr(attrs, attr)
+		return true
+	})
+	entry.Write(h.withFields(attrs)...)
+	return nil
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var fields []zap.Field
+	for _, attr := range attrs {
+		fields = appendSlogAttr(fields, attr)
+	}
+	if len(fields) == 0 {
+		return h
+	}
+	return &slogHandler{logger: h.logger, fields: h.withFields(fields)}
+}
+
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	groups := make([]string, 0, len(h.groups)+1)
+	groups = append(groups, h.groups...)
+	groups = append(groups, name)
+	return &slogHandler{logger: h.logger, fields: h.fields, groups: groups}
+}
+
+// withFields returns the handler's fields followed by attrs, opening the
+// pending groups first. Without attrs the groups stay closed.
+func (h *slogHandler) withFields(attrs []zap.Field) []zap.Field {
+	if len(attrs) == 0 {
+		return h.fields
+	}
+	fields := make([]zap.Field, 0, len(h.fields)+len(h.groups)+len(attrs))
+	fields = append(fields, h.fields...)
+	for _, group := range h.groups {
+		fields = append(fields, zap.Namespace(group))
+	}
+	return append(fields, attrs...)
+}
+
+// slogLevel maps a slog level to the closest zap level at or below it.
+func slogLevel(level slog.Level) zapcore.Level {
+	switch {
+	case level >= slog.LevelError:
+		return zapcore.ErrorLevel
+	case level >= slog.LevelWarn:
+		return zapcore.WarnLevel
+	case level >= slog.LevelInfo:
+		return zapcore.InfoLevel
+	default:
+		return zapcore.DebugLevel
+	}
+}
+
+// appendSlogAttr converts attr to zap fields following the slog.Handler
+// rules: empty attributes are dropped and groups without a key are inlined.
+func appendSlogAttr(fields []zap.Field, attr slog.Attr) []zap.Field {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return fields
+	}
+
+	if attr.Value.Kind() != slog.KindGroup {
+		return append(fields, zap.Any(attr.Key, attr.Value.Any()))
+	}
+
+	var group []zap.Field
+	for _, groupAttr := range attr.Value.Group() {
+		group = appendSlogAttr(group, groupAttr)
+	}
+	if attr.Key == "" {
+		return append(fields, group...)
+	}
+	if len(group) == 0 {
+		return fields
+	}
+	return append(fields, zap.Dict(attr.Key, group...))
+}
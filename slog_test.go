@@ -0,0 +1,93 @@
+package logger_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+	"testing/slogtest"
+	"time"
+
+	"github.com/cyrus-wg/go-logger"
+)
+
+func TestSlogHandler(t *testing.T) {
+	var buf *bytes.Buffer
+	newHandler := func(t *testing.T) slog.Handler {
+		if t.Name() == "TestSlogHandler/zero-time" {
+			t.Skip("a zero record time is replaced by the time the entry was checked")
+		}
+		var l *logger.Logger
+		l, buf = newLogger(t, logger.LoggerConfig{
+			EncoderKeys: logger.EncoderKeys{
+				Message: slog.MessageKey,
+				Level:   slog.LevelKey,
+				Time:    slog.TimeKey,
+			},
+		})
+		return l.SlogHandler()
+	}
+	result := func(t *testing.T) map[string]any {
+		return decodeLine(t, buf)
+	}
+	slogtest.Run(t, newHandler, result)
+}
+
+func TestSlogHandlerEmptyGroups(t *testing.T) {
+	tests := []struct {
+		name string
+		log  func(l *slog.Logger)
+		want string
+	}{
+		{
+			name: "group without attributes",
+			log:  func(l *slog.Logger) { l.WithGroup("G").Info("msg") },
+			want: `{"level":"INFO","message":"msg"}`,
+		},
+		{
+			name: "nested groups without attributes",
+			log:  func(l *slog.Logger) { l.WithGroup("G").WithGroup("H").Info("msg") },
+			want: `{"level":"INFO","message":"msg"}`,
+		},
+		{
+			name: "group with record attributes",
+			log:  func(l *slog.Logger) { l.WithGroup("G").WithGroup("H").Info("msg", "a", "b") },
+			want: `{"level":"INFO","message":"msg","G":{"H":{"a":"b"}}}`,
+		},
+		{
+			name: "group with bound attributes",
+			log:  func(l *slog.Logger) { l.WithGroup("G").With("a", "b").WithGroup("H").Info("msg") },
+			want: `{"level":"INFO","message":"msg","G":{"a":"b"}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, buf := newLogger(t, logger.LoggerConfig{
+				DisableTimestamp: true,
+				DisableCaller:    true,
+			})
+			tt.log(slog.New(l.SlogHandler()))
+			if got := string(bytes.TrimSpace(buf.Bytes())); got != tt.want {
+				t.Errorf("got  %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSlogHandlerZeroTime(t *testing.T) {
+	l, buf := newLogger(t, logger.LoggerConfig{})
+	before := time.Now()
+	record := slog.NewRecord(time.Time{}, slog.LevelInfo, "msg", 0)
+	if err := l.SlogHandler().Handle(context.Background(), record); err != nil {
+		t.Fatalf("Handle: %v", err)
+	}
+
+	line := decodeLine(t, buf)
+	logged, err := time.Parse(time.RFC3339Nano, line["@timestamp"].(string))
+	if err != nil {
+		t.Fatalf("@timestamp %v: %v", line["@timestamp"], err)
+	}
+	if logged.Before(before.Truncate(time.Millisecond)) {
+		t.Errorf("@timestamp = %v, want the current time, not %v", logged, before)
+	}
+}
@@ -0,0 +1,69 @@
+package logger
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// validateKeysAndValues drops the pairs of a sugared key-value slice that
+// zap would mishandle, a key without a value and keys that are not strings,
+// and describes each one dropped. A bare error takes no value, as in zap, but
+// only one is allowed since each is logged under "error".
+func validateKeysAndValues(keysAndValues []any) ([]any, []string) {
+	var problems []string
+	valid := keysAndValues[:0:0]
+	seenError := false
+
+	for i := 0; i < len(keysAndValues); {
+		if _, ok := keysAndValues[i].(zapcore.Field); ok {
+			valid = append(valid, keysAndValues[i])
+			i++
+			continue
+		}
+		if err, ok := keysAndValues[i].(error); ok {
+			if seenError {
+				problems = append(problems, fmt.Sprintf("error %q has no key and another error was given", err))
+			} else {
+				valid = append(valid, err)
+				seenError = true
+			}
+			i++
+			continue
+		}
+
+		if i+1 >= len(keysAndValues) {
+			problems = append(problems, fmt.Sprintf("key %v has no value", keysAndValues[i]))
+			break
+		}
+		if _, ok := keysAndValues[i].(string); !ok {
+			problems = append(problems, fmt.Sprintf("key %v is a %T, not a string", keysAndValues[i], keysAndValues[i]))
+		} else {
+			valid = append(valid, keysAndValues[i], keysAndValues[i+1])
+		}
+		i += 2
+	}
+
+	if len(problems) == 0 {
+		return keysAndValues, nil
+	}
+	return valid, problems
+}
+
+// errorsToFields replaces each bare error in a sugared key-value slice with
+// zap.Error, in place, as zap's sugared logger does, so the code walking the
+// pairs afterwards sees a field instead of a key without a value.
+func errorsToFields(keysAndValues []any) {
+	for i := 0; i < len(keysAndValues); {
+		switch value := keysAndValues[i].(type) {
+		case zapcore.Field:
+			i++
+		case error:
+			keysAndValues[i] = zap.Error(value)
+			i++
+		default:
+			i += 2
+		}
+	}
+}
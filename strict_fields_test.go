@@ -0,0 +1,99 @@
+package logger_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/cyrus-wg/go-logger"
+)
+
+func TestStrictFields(t *testing.T) {
+	errFailed := errors.New("boom")
+	tests := []struct {
+		name          string
+		config        logger.LoggerConfig
+		keysAndValues []any
+		want          map[string]any
+		wantWarning   bool
+	}{
+		{
+			name:          "bare error",
+			config:        logger.LoggerConfig{Development: true},
+			keysAndValues: []any{errFailed, "k", "v"},
+			want:          map[string]any{"error": "boom", "k": "v"},
+		},
+		{
+			name:          "bare error without strict fields",
+			config:        logger.LoggerConfig{},
+			keysAndValues: []any{"k", "v", errFailed},
+			want:          map[string]any{"error": "boom", "k": "v"},
+		},
+		{
+			name:          "second bare error",
+			config:        logger.LoggerConfig{StrictFields: true},
+			keysAndValues: []any{errFailed, errors.New("other"), "k", "v"},
+			want:          map[string]any{"error": "boom", "k": "v"},
+			wantWarning:   true,
+		},
+		{
+			name:          "missing value",
+			config:        logger.LoggerConfig{Development: true},
+			keysAndValues: []any{"k", "v", "orphan"},
+			want:          map[string]any{"k": "v"},
+			wantWarning:   true,
+		},
+		{
+			name:          "non-string key",
+			config:        logger.LoggerConfig{StrictFields: true},
+			keysAndValues: []any{42, "v", "k", "v"},
+			want:          map[string]any{"k": "v"},
+			wantWarning:   true,
+		},
+		{
+			name:          "disabled in development",
+			config:        logger.LoggerConfig{Development: true, DisableStrictFields: true},
+			keysAndValues: []any{"k", "v", 42, "x"},
+			want:          map[string]any{"k": "v"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.Encoding = "json"
+			l, buf := newLogger(t, tt.config)
+			ctx := l.SetRequestID(context.Background(), "req-1")
+
+			l.Errorw(ctx, "failed", tt.keysAndValues...)
+			lines := decodeLines(t, buf)
+
+			var entry, warning map[string]any
+			for _, line := range lines {
+				switch line["message"] {
+				case "failed":
+					entry = line
+				case "Ignored invalid log fields":
+					warning = line
+				}
+			}
+			if entry == nil {
+				t.Fatalf("entry not logged:\n%s", buf)
+			}
+			for k, v := range tt.want {
+				if entry[k] != v {
+					t.Errorf("%s = %v, want %v", k, entry[k], v)
+				}
+			}
+			if (warning != nil) != tt.wantWarning {
+				t.Fatalf("warning logged = %t, want %t:\n%s", warning != nil, tt.wantWarning, buf)
+			}
+			if warning != nil {
+				if warning["request_id"] != "req-1" {
+					t.Errorf("warning request_id = %v, want req-1", warning["request_id"])
+				}
+				if warning["log_message"] != "failed" {
+					t.Errorf("warning log_message = %v, want failed", warning["log_message"])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package logger
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// truncateAttributes shortens string and []byte values longer than
+// maxFieldBytes in a sugared key-value slice in place. Strongly-typed
+// zap.Field entries are handled as well.
+func (l *Logger) truncateAttributes(keysAndValues []any) {
+	if l.maxFieldBytes <= 0 {
+		return
+	}
+
+	for i := 0; i < len(keysAndValues); {
+		if field, ok := keysAndValues[i].(zapcore.Field); ok {
+			keysAndValues[i] = l.truncateField(field)
+			i++
+			continue
+		}
+
+		if i+1 >= len(keysAndValues) {
+			break
+		}
+		switch value := keysAndValues[i+1].(type) {
+		case string:
+			if len(value) > l.maxFieldBytes {
+				keysAndValues[i+1] = truncateValue(value, l.maxFieldBytes)
+			}
+		case []byte:
+			if len(value) > l.maxFieldBytes {
+				keysAndValues[i+1] = truncateValue(value, l.maxFieldBytes)
+			}
+		}
+		i += 2
+	}
+}
+
+// truncateValue cuts v, known to be longer than max bytes, back to a rune
+// boundary at or below max and appends a marker with the number of bytes
+// dropped. The result is always a string so the marker stays readable.
+func truncateValue[T string | []byte](v T, max int) string {
+	cut := max
+	for cut > 0 && !utf8.RuneStart(v[cut]) {
+		cut--
+	}
+	return fmt.Sprintf("%s...[truncated %d bytes]", v[:cut], len(v)-cut)
+}
+
+// truncateField shortens the value of a string or []byte field longer than
+// maxFieldBytes.
+func (l *Logger) truncateField(field zapcore.Field) zapcore.Field {
+	if l.maxFieldBytes <= 0 {
+		return field
+	}
+
+	switch field.Type {
+	case zapcore.StringType:
+		if len(field.String) > l.maxFieldBytes {
+			return zap.String(field.Key, truncateValue(field.String, l.maxFieldBytes))
+		}
+	case zapcore.ByteStringType, zapcore.BinaryType:
+		if b, ok := field.Interface.([]byte); ok && len(b) > l.maxFieldBytes {
+			return zap.String(field.Key, truncateValue(b, l.maxFieldBytes))
+		}
+	}
+	return field
+}
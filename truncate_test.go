@@ -0,0 +1,52 @@
+package logger_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/cyrus-wg/go-logger"
+	"go.uber.org/zap"
+)
+
+func TestMaxFieldBytes(t *testing.T) {
+	long := strings.Repeat("x", 20)
+	tests := []struct {
+		name  string
+		value any
+		want  any
+	}{
+		{"short string", "short", "short"},
+		{"exact string", long[:10], long[:10]},
+		{"long string", long, "xxxxxxxxxx...[truncated 10 bytes]"},
+		{"long bytes", []byte(long), "xxxxxxxxxx...[truncated 10 bytes]"},
+		{"rune boundary", "xxxxxxxxx€€", "xxxxxxxxx...[truncated 6 bytes]"},
+		{"zap.String", zap.String("value", long), "xxxxxxxxxx...[truncated 10 bytes]"},
+		{"zap.ByteString", zap.ByteString("value", []byte(long)), "xxxxxxxxxx...[truncated 10 bytes]"},
+		{"not a string", 12345678901234, float64(12345678901234)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, buf := newLogger(t, logger.LoggerConfig{MaxFieldBytes: 10})
+			if field, ok := tt.value.(zap.Field); ok {
+				l.Infow(context.Background(), long, field)
+			} else {
+				l.Infow(context.Background(), long, "value", tt.value)
+			}
+
+			line := decodeLine(t, buf)
+			if line["value"] != tt.want {
+				t.Errorf("value = %v, want %v", line["value"], tt.want)
+			}
+			if line["message"] != long {
+				t.Errorf("message = %v, want it untruncated", line["message"])
+			}
+		})
+	}
+
+	l, buf := newLogger(t, logger.LoggerConfig{MaxFieldBytes: 10})
+	l.LogFields(context.Background(), logger.InfoLevel, "msg", zap.String("value", long))
+	if got, want := decodeLine(t, buf)["value"], "xxxxxxxxxx...[truncated 10 bytes]"; got != want {
+		t.Errorf("LogFields value = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,46 @@
+package logger
+
+import (
+	"context"
+	"io"
+	"log"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// levelWriter logs each Write as one entry at a fixed level.
+type levelWriter struct {
+	logger *Logger
+	level  zapcore.Level
+}
+
+func (w *levelWriter) Write(p []byte) (int, error) {
+	w.logger.log(context.Background(), w.level, strings.TrimSuffix(string(p), "\n"), nil)
+	return len(p), nil
+}
+
+func (l *Logger) newLevelWriter(level Level, callerSkip int) *levelWriter {
+	zl, err := level.zapLevel()
+	if err != nil {
+		zl = zapcore.InfoLevel
+	}
+
+	child := *l
+	child.setBase(l.base.WithOptions(zap.AddCallerSkip(callerSkip)), l.traceBase.WithOptions(zap.AddCallerSkip(callerSkip)))
+	return &levelWriter{logger: &child, level: zl}
+}
+
+// Writer returns an io.Writer that logs each Write as one entry at level,
+// with a trailing newline trimmed. An unknown level logs at Info.
+func (l *Logger) Writer(level Level) io.Writer {
+	return l.newLevelWriter(level, 0)
+}
+
+// StdLogAt returns a standard library *log.Logger that logs through l at
+// level, e.g. for http.Server.ErrorLog.
+func (l *Logger) StdLogAt(level Level) *log.Logger {
+	// Skip log.(*Logger).output and the Print method that called it
+	return log.New(l.newLevelWriter(level, 2), "", 0)
+}
@@ -0,0 +1,43 @@
+package logger_test
+
+import (
+	"testing"
+
+	"github.com/cyrus-wg/go-logger"
+)
+
+func TestStdLogAt(t *testing.T) {
+	tests := []struct {
+		name  string
+		level logger.Level
+		write func(l *logger.Logger, level logger.Level)
+		want  string
+	}{
+		{"StdLogAt Printf", logger.WarnLevel, func(l *logger.Logger, level logger.Level) {
+			l.StdLogAt(level).Printf("http: TLS handshake error from %s", "10.0.0.1")
+		}, "WARN"},
+		{"StdLogAt Println", logger.ErrorLevel, func(l *logger.Logger, level logger.Level) {
+			l.StdLogAt(level).Println("http: TLS handshake error from 10.0.0.1")
+		}, "ERROR"},
+		{"Writer", logger.InfoLevel, func(l *logger.Logger, level logger.Level) {
+			l.Writer(level).Write([]byte("http: TLS handshake error from 10.0.0.1\n"))
+		}, "INFO"},
+		{"unknown level", logger.Level(42), func(l *logger.Logger, level logger.Level) {
+			l.Writer(level).Write([]byte("http: TLS handshake error from 10.0.0.1"))
+		}, "INFO"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, buf := newLogger(t, logger.LoggerConfig{DisableStacktrace: true})
+			tt.write(l, tt.level)
+
+			line := decodeLine(t, buf)
+			if line["message"] != "http: TLS handshake error from 10.0.0.1" {
+				t.Errorf("message = %q, want it without the trailing newline", line["message"])
+			}
+			if line["level"] != tt.want {
+				t.Errorf("level = %v, want %s", line["level"], tt.want)
+			}
+		})
+	}
+}